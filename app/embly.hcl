@@ -46,6 +46,7 @@ files "star-playground" {
 files "frontend" {
   path              = "./frontend/build/"
   local_file_server = "http://localhost:3000"
+  spa_fallback      = "index.html"
 }
 
 
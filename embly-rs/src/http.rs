@@ -43,10 +43,12 @@ use http;
 use http::header::{HeaderName, HeaderValue};
 use http::response::Parts;
 use http::status::StatusCode;
+use http::HeaderMap;
 use http::HttpTryFrom;
 pub use http::Request;
 pub use http::Response;
 use httparse;
+use std::collections::HashMap;
 use std::future::Future;
 use std::io;
 use std::io::Read;
@@ -54,18 +56,41 @@ use std::io::Write;
 use std::sync::Arc;
 use std::sync::Mutex;
 
+/// Path parameters matched by the gateway route, available as a request
+/// extension
+///
+/// ```no_run
+/// # use embly::http::{Body, Params, Request};
+/// fn user_id(req: &Request<Body>) -> Option<&str> {
+///     req.extensions()
+///         .get::<Params>()
+///         .and_then(|params| params.get("id"))
+///         .map(String::as_str)
+/// }
+/// ```
+#[derive(Debug, Default, Clone, PartialEq)]
+pub struct Params(pub HashMap<String, String>);
+
+impl std::ops::Deref for Params {
+    type Target = HashMap<String, String>;
+    fn deref(&self) -> &Self::Target {
+        &self.0
+    }
+}
+
 /// An http body
 #[derive(Debug, Default)]
 pub struct Body {
     conn: Conn,
-    content_length: Option<usize>,
-    read_count: usize,
+    // set once the message that ends the request body has been read
+    eof: bool,
     read_buf: Vec<u8>,
 }
 
 struct Interior {
     body: Body,
     parts: Parts,
+    trailers: HeaderMap,
     write_buf: Vec<u8>,
 }
 
@@ -73,40 +98,42 @@ impl Body {
     /// waits for all body bytes and returns them
     pub fn bytes(&mut self) -> Result<Vec<u8>, Error> {
         let mut out: Vec<u8> = self.read_buf.drain(..).collect();
-        if self.content_length.is_none() || self.content_length.unwrap() == 0 {
-            return Ok(out);
-        }
-        self.read_count = out.len();
-        if self.read_count == self.content_length.unwrap() {
-            Ok(out)
-        } else {
-            while self.read_count < self.content_length.unwrap() {
-                let mut http = proto::next_message(&mut self.conn)?;
-                self.read_count += http.body.len();
-                out.append(&mut http.body);
-            }
-            Ok(out)
+        while !self.eof {
+            let mut http = proto::next_message(&mut self.conn)?;
+            self.eof = http.eof;
+            out.append(&mut http.body);
         }
+        Ok(out)
     }
 }
 
-impl Interior {
-    fn header<K, V>(&mut self, key: K, value: V) -> Result<(), Error>
-    where
-        HeaderName: HttpTryFrom<K>,
-        HeaderValue: HttpTryFrom<V>,
-    {
-        match HeaderName::try_from(key) {
-            Ok(key) => match HeaderValue::try_from(value) {
-                Ok(value) => {
-                    self.parts.headers.insert(key, value);
-                    Ok(())
-                }
-                Err(e) => Err(EmblyError::Http(e.into()).into()),
-            },
+fn insert_header<K, V>(headers: &mut HeaderMap, key: K, value: V) -> Result<(), Error>
+where
+    HeaderName: HttpTryFrom<K>,
+    HeaderValue: HttpTryFrom<V>,
+{
+    match HeaderName::try_from(key) {
+        Ok(key) => match HeaderValue::try_from(value) {
+            Ok(value) => {
+                headers.insert(key, value);
+                Ok(())
+            }
             Err(e) => Err(EmblyError::Http(e.into()).into()),
+        },
+        Err(e) => Err(EmblyError::Http(e.into()).into()),
+    }
+}
+
+fn header_lists(headers: &mut HeaderMap) -> Result<HashMap<String, HeaderList>, Error> {
+    let mut out = HashMap::new();
+    for (name, values) in headers.drain() {
+        let mut list = HeaderList::default();
+        for value in values {
+            list.header.push(value.to_str()?.to_string());
         }
+        out.insert(name.as_str().to_string(), list);
     }
+    Ok(out)
 }
 
 /// An http response writer. Used to write an http response, can either be used to write
@@ -151,6 +178,7 @@ impl ResponseWriter {
             interior: Arc::new(Mutex::new(Interior {
                 body: body,
                 parts: p,
+                trailers: HeaderMap::new(),
                 write_buf: Vec::new(),
             })),
         }
@@ -161,7 +189,15 @@ impl ResponseWriter {
         HeaderName: HttpTryFrom<K>,
         HeaderValue: HttpTryFrom<V>,
     {
-        self.interior.lock().unwrap().header(key, value)
+        insert_header(&mut self.interior.lock().unwrap().parts.headers, key, value)
+    }
+    /// add a trailer to this response, trailers are sent after the response body
+    pub fn trailer<K, V>(&mut self, key: K, value: V) -> Result<(), Error>
+    where
+        HeaderName: HttpTryFrom<K>,
+        HeaderValue: HttpTryFrom<V>,
+    {
+        insert_header(&mut self.interior.lock().unwrap().trailers, key, value)
     }
     /// set the http status for the response
     pub fn status<T>(&mut self, status: T) -> Result<(), Error>
@@ -204,17 +240,12 @@ impl Flusher for ResponseWriter {
 
         if !self.headers_written {
             http_msg.status = interior.parts.status.as_u16() as i32;
-            for (name, values) in interior.parts.headers.drain() {
-                let mut list = HeaderList::default();
-                for value in values {
-                    list.header.push(value.to_str()?.to_string());
-                }
-                http_msg.headers.insert(name.as_str().to_string(), list);
-            }
+            http_msg.headers = header_lists(&mut interior.parts.headers)?;
             self.headers_written = true;
         }
         if self.function_returned {
             http_msg.eof = true;
+            http_msg.trailers = header_lists(&mut interior.trailers)?;
         }
         http_msg.body = interior.write_buf.drain(..).collect();
         proto::write_msg(&mut interior.body.conn, http_msg)?;
@@ -264,20 +295,21 @@ fn http_proto_to_request(http: Http) -> Request<Body> {
     request.uri(http.uri);
     // hardcode a map?
     let mut body = Body::default();
-    request.method(format!("{:?}", http.method).as_str());
+    if http.method_name.is_empty() {
+        request.method(format!("{:?}", http.method).as_str());
+    } else {
+        request.method(http.method_name.as_str());
+    }
     for (h, values) in http.headers {
-        if h == "Content-Length" {
-            let cl: usize = values.header[0]
-                .parse()
-                .expect("content length should be an int");
-            body.content_length = Some(cl);
-        }
         for v in values.header {
             request.header(&h, v);
         }
     }
     body.read_buf = http.body;
-    request.body(body).expect("should be able to create a body")
+    body.eof = http.eof;
+    let mut request = request.body(body).expect("should be able to create a body");
+    request.extensions_mut().insert(Params(http.params));
+    request
 }
 
 // Will be used, currently just used for tests
@@ -25,6 +25,14 @@ pub struct Http {
     pub method: httpproto::mod_Http::Method,
     pub body: Vec<u8>,
     pub eof: bool,
+    pub method_name: String,
+    pub remote_addr: String,
+    pub scheme: String,
+    pub host: String,
+    pub query: String,
+    pub tls: Option<httpproto::TLS>,
+    pub trailers: HashMap<String, httpproto::HeaderList>,
+    pub params: HashMap<String, String>,
 }
 
 impl<'a> MessageRead<'a> for Http {
@@ -43,6 +51,20 @@ impl<'a> MessageRead<'a> for Http {
                 Ok(48) => msg.method = r.read_enum(bytes)?,
                 Ok(58) => msg.body = r.read_bytes(bytes)?.to_owned(),
                 Ok(64) => msg.eof = r.read_bool(bytes)?,
+                Ok(74) => msg.method_name = r.read_string(bytes)?.to_owned(),
+                Ok(82) => msg.remote_addr = r.read_string(bytes)?.to_owned(),
+                Ok(90) => msg.scheme = r.read_string(bytes)?.to_owned(),
+                Ok(98) => msg.host = r.read_string(bytes)?.to_owned(),
+                Ok(106) => msg.query = r.read_string(bytes)?.to_owned(),
+                Ok(114) => msg.tls = Some(r.read_message::<httpproto::TLS>(bytes)?),
+                Ok(122) => {
+                    let (key, value) = r.read_map(bytes, |r, bytes| Ok(r.read_string(bytes)?.to_owned()), |r, bytes| Ok(r.read_message::<httpproto::HeaderList>(bytes)?))?;
+                    msg.trailers.insert(key, value);
+                }
+                Ok(130) => {
+                    let (key, value) = r.read_map(bytes, |r, bytes| Ok(r.read_string(bytes)?.to_owned()), |r, bytes| Ok(r.read_string(bytes)?.to_owned()))?;
+                    msg.params.insert(key, value);
+                }
                 Ok(t) => { r.read_unknown(bytes, t)?; }
                 Err(e) => return Err(e),
             }
@@ -62,6 +84,14 @@ impl MessageWrite for Http {
         + if self.method == httpproto::mod_Http::Method::GET { 0 } else { 1 + sizeof_varint(*(&self.method) as u64) }
         + if self.body == vec![] { 0 } else { 1 + sizeof_len((&self.body).len()) }
         + if self.eof == false { 0 } else { 1 + sizeof_varint(*(&self.eof) as u64) }
+        + if self.method_name == String::default() { 0 } else { 1 + sizeof_len((&self.method_name).len()) }
+        + if self.remote_addr == String::default() { 0 } else { 1 + sizeof_len((&self.remote_addr).len()) }
+        + if self.scheme == String::default() { 0 } else { 1 + sizeof_len((&self.scheme).len()) }
+        + if self.host == String::default() { 0 } else { 1 + sizeof_len((&self.host).len()) }
+        + if self.query == String::default() { 0 } else { 1 + sizeof_len((&self.query).len()) }
+        + self.tls.as_ref().map_or(0, |m| 1 + sizeof_len((m).get_size()))
+        + self.trailers.iter().map(|(k, v)| 1 + sizeof_len(2 + sizeof_len((k).len()) + sizeof_len((v).get_size()))).sum::<usize>()
+        + self.params.iter().map(|(k, v)| 2 + sizeof_len(2 + sizeof_len((k).len()) + sizeof_len((v).len()))).sum::<usize>()
     }
 
     fn write_message<W: Write>(&self, w: &mut Writer<W>) -> Result<()> {
@@ -73,6 +103,14 @@ impl MessageWrite for Http {
         if self.method != httpproto::mod_Http::Method::GET { w.write_with_tag(48, |w| w.write_enum(*&self.method as i32))?; }
         if self.body != vec![] { w.write_with_tag(58, |w| w.write_bytes(&**&self.body))?; }
         if self.eof != false { w.write_with_tag(64, |w| w.write_bool(*&self.eof))?; }
+        if self.method_name != String::default() { w.write_with_tag(74, |w| w.write_string(&**&self.method_name))?; }
+        if self.remote_addr != String::default() { w.write_with_tag(82, |w| w.write_string(&**&self.remote_addr))?; }
+        if self.scheme != String::default() { w.write_with_tag(90, |w| w.write_string(&**&self.scheme))?; }
+        if self.host != String::default() { w.write_with_tag(98, |w| w.write_string(&**&self.host))?; }
+        if self.query != String::default() { w.write_with_tag(106, |w| w.write_string(&**&self.query))?; }
+        if let Some(ref s) = self.tls { w.write_with_tag(114, |w| w.write_message(s))?; }
+        for (k, v) in self.trailers.iter() { w.write_with_tag(122, |w| w.write_map(2 + sizeof_len((k).len()) + sizeof_len((v).get_size()), 10, |w| w.write_string(&**k), 18, |w| w.write_message(v)))?; }
+        for (k, v) in self.params.iter() { w.write_with_tag(130, |w| w.write_map(2 + sizeof_len((k).len()) + sizeof_len((v).len()), 10, |w| w.write_string(&**k), 18, |w| w.write_string(&**v)))?; }
         Ok(())
     }
 }
@@ -90,6 +128,8 @@ pub enum Method {
     OPTIONS = 5,
     TRACE = 6,
     CONNECT = 7,
+    HEAD = 8,
+    OTHER = 9,
 }
 
 impl Default for Method {
@@ -109,6 +149,8 @@ impl From<i32> for Method {
             5 => Method::OPTIONS,
             6 => Method::TRACE,
             7 => Method::CONNECT,
+            8 => Method::HEAD,
+            9 => Method::OTHER,
             _ => Self::default(),
         }
     }
@@ -125,6 +167,8 @@ impl<'a> From<&'a str> for Method {
             "OPTIONS" => Method::OPTIONS,
             "TRACE" => Method::TRACE,
             "CONNECT" => Method::CONNECT,
+            "HEAD" => Method::HEAD,
+            "OTHER" => Method::OTHER,
             _ => Self::default(),
         }
     }
@@ -163,3 +207,124 @@ impl MessageWrite for HeaderList {
     }
 }
 
+#[derive(Debug, Default, PartialEq, Clone)]
+pub struct Frame {
+    pub type_pb: httpproto::mod_Frame::Type,
+    pub data: Vec<u8>,
+    pub close_code: i32,
+}
+
+impl<'a> MessageRead<'a> for Frame {
+    fn from_reader(r: &mut BytesReader, bytes: &'a [u8]) -> Result<Self> {
+        let mut msg = Self::default();
+        while !r.is_eof() {
+            match r.next_tag(bytes) {
+                Ok(8) => msg.type_pb = r.read_enum(bytes)?,
+                Ok(18) => msg.data = r.read_bytes(bytes)?.to_owned(),
+                Ok(24) => msg.close_code = r.read_int32(bytes)?,
+                Ok(t) => { r.read_unknown(bytes, t)?; }
+                Err(e) => return Err(e),
+            }
+        }
+        Ok(msg)
+    }
+}
+
+impl MessageWrite for Frame {
+    fn get_size(&self) -> usize {
+        0
+        + if self.type_pb == httpproto::mod_Frame::Type::TEXT { 0 } else { 1 + sizeof_varint(*(&self.type_pb) as u64) }
+        + if self.data == vec![] { 0 } else { 1 + sizeof_len((&self.data).len()) }
+        + if self.close_code == 0i32 { 0 } else { 1 + sizeof_varint(*(&self.close_code) as u64) }
+    }
+
+    fn write_message<W: Write>(&self, w: &mut Writer<W>) -> Result<()> {
+        if self.type_pb != httpproto::mod_Frame::Type::TEXT { w.write_with_tag(8, |w| w.write_enum(*&self.type_pb as i32))?; }
+        if self.data != vec![] { w.write_with_tag(18, |w| w.write_bytes(&**&self.data))?; }
+        if self.close_code != 0i32 { w.write_with_tag(24, |w| w.write_int32(*&self.close_code))?; }
+        Ok(())
+    }
+}
+
+pub mod mod_Frame {
+
+
+#[derive(Debug, PartialEq, Eq, Clone, Copy)]
+pub enum Type {
+    TEXT = 0,
+    BINARY = 1,
+    CLOSE = 2,
+}
+
+impl Default for Type {
+    fn default() -> Self {
+        Type::TEXT
+    }
+}
+
+impl From<i32> for Type {
+    fn from(i: i32) -> Self {
+        match i {
+            0 => Type::TEXT,
+            1 => Type::BINARY,
+            2 => Type::CLOSE,
+            _ => Self::default(),
+        }
+    }
+}
+
+impl<'a> From<&'a str> for Type {
+    fn from(s: &'a str) -> Self {
+        match s {
+            "TEXT" => Type::TEXT,
+            "BINARY" => Type::BINARY,
+            "CLOSE" => Type::CLOSE,
+            _ => Self::default(),
+        }
+    }
+}
+
+}
+
+#[derive(Debug, Default, PartialEq, Clone)]
+pub struct TLS {
+    pub version: String,
+    pub cipher_suite: String,
+    pub server_name: String,
+    pub negotiated_protocol: String,
+}
+
+impl<'a> MessageRead<'a> for TLS {
+    fn from_reader(r: &mut BytesReader, bytes: &'a [u8]) -> Result<Self> {
+        let mut msg = Self::default();
+        while !r.is_eof() {
+            match r.next_tag(bytes) {
+                Ok(10) => msg.version = r.read_string(bytes)?.to_owned(),
+                Ok(18) => msg.cipher_suite = r.read_string(bytes)?.to_owned(),
+                Ok(26) => msg.server_name = r.read_string(bytes)?.to_owned(),
+                Ok(34) => msg.negotiated_protocol = r.read_string(bytes)?.to_owned(),
+                Ok(t) => { r.read_unknown(bytes, t)?; }
+                Err(e) => return Err(e),
+            }
+        }
+        Ok(msg)
+    }
+}
+
+impl MessageWrite for TLS {
+    fn get_size(&self) -> usize {
+        0
+        + if self.version == String::default() { 0 } else { 1 + sizeof_len((&self.version).len()) }
+        + if self.cipher_suite == String::default() { 0 } else { 1 + sizeof_len((&self.cipher_suite).len()) }
+        + if self.server_name == String::default() { 0 } else { 1 + sizeof_len((&self.server_name).len()) }
+        + if self.negotiated_protocol == String::default() { 0 } else { 1 + sizeof_len((&self.negotiated_protocol).len()) }
+    }
+
+    fn write_message<W: Write>(&self, w: &mut Writer<W>) -> Result<()> {
+        if self.version != String::default() { w.write_with_tag(10, |w| w.write_string(&**&self.version))?; }
+        if self.cipher_suite != String::default() { w.write_with_tag(18, |w| w.write_string(&**&self.cipher_suite))?; }
+        if self.server_name != String::default() { w.write_with_tag(26, |w| w.write_string(&**&self.server_name))?; }
+        if self.negotiated_protocol != String::default() { w.write_with_tag(34, |w| w.write_string(&**&self.negotiated_protocol))?; }
+        Ok(())
+    }
+}
@@ -17,36 +17,231 @@ use crate::{spawn_and_send, spawn_function};
 use failure::{err_msg, Error};
 use std::future::Future;
 
-/// Set a binary key and value.
+/// Set a binary key and value in the default namespace.
 /// Any existing value will be overwritten. Keys can
 /// be no larger than 10,000kb and values can be no larger than 100,000kb
 pub fn set(key: &[u8], value: &[u8]) -> impl Future<Output = Result<(), Error>> {
-    let result = spawn_function("embly/kv/set");
-    let write_result = write_key_and_value(key, value);
-    async move {
-        let mut conn = result?;
-        let to_send = write_result?;
-        conn.write(&to_send)?;
-        Ok(())
-    }
+    Namespace::default().set(key, value)
+}
+
+/// Set a binary key and value in the default namespace that expires after `ttl`
+pub fn set_with_ttl(
+    key: &[u8],
+    value: &[u8],
+    ttl: std::time::Duration,
+) -> impl Future<Output = Result<(), Error>> {
+    Namespace::default().set_with_ttl(key, value, ttl)
 }
 
-/// Get a key
+/// Get a key from the default namespace
 pub fn get(key: &[u8]) -> impl Future<Output = Result<Vec<u8>, Error>> {
-    let result = spawn_and_send("embly/kv/get", key);
-    async move {
-        let mut conn = result?;
-        conn.await?;
-        let mut out = Vec::new();
-        conn.read_to_end(&mut out)?;
-        Ok(out)
+    Namespace::default().get(key)
+}
+
+/// Delete a key from the default namespace
+pub fn delete(key: &[u8]) -> impl Future<Output = Result<(), Error>> {
+    Namespace::default().delete(key)
+}
+
+/// Check if a key exists in the default namespace
+pub fn exists(key: &[u8]) -> impl Future<Output = Result<bool, Error>> {
+    Namespace::default().exists(key)
+}
+
+/// Scan keys that start with a prefix in the default namespace
+pub fn scan(
+    prefix: &[u8],
+    limit: u32,
+) -> impl Future<Output = Result<Vec<(Vec<u8>, Vec<u8>)>, Error>> {
+    Namespace::default().scan(prefix, limit)
+}
+
+/// Compare and swap a value in the default namespace
+pub fn cas(
+    key: &[u8],
+    old: Option<&[u8]>,
+    value: &[u8],
+) -> impl Future<Output = Result<bool, Error>> {
+    Namespace::default().cas(key, old, value)
+}
+
+/// A kv namespace declared with a `kv` block in embly.hcl. Keys in one namespace
+/// are kept apart from keys in every other namespace
+pub struct Namespace {
+    name: String,
+}
+
+/// Use a kv namespace
+pub fn namespace(name: &str) -> Namespace {
+    Namespace {
+        name: name.to_string(),
     }
 }
 
+impl Default for Namespace {
+    fn default() -> Self {
+        namespace("default")
+    }
+}
+
+impl Namespace {
+    fn path(&self, command: &str) -> String {
+        format!("embly/kv/{}/{}", self.name, command)
+    }
+
+    /// Set a binary key and value.
+    /// Any existing value will be overwritten. Keys can
+    /// be no larger than 10,000kb and values can be no larger than 100,000kb
+    pub fn set(&self, key: &[u8], value: &[u8]) -> impl Future<Output = Result<(), Error>> {
+        let result = spawn_function(&self.path("set"));
+        let write_result = write_key_and_value(key, value);
+        async move {
+            let mut conn = result?;
+            let to_send = write_result?;
+            conn.write(&to_send)?;
+            Ok(())
+        }
+    }
+
+    /// Set a binary key and value that expires after `ttl`. Expired keys are
+    /// treated as if they don't exist. A `ttl` of zero never expires
+    pub fn set_with_ttl(
+        &self,
+        key: &[u8],
+        value: &[u8],
+        ttl: std::time::Duration,
+    ) -> impl Future<Output = Result<(), Error>> {
+        let result = spawn_function(&self.path("set"));
+        let write_result = write_key_and_value(key, value).map(|mut out| {
+            let ms = ttl.as_millis() as u64;
+            if ms > 0 {
+                let key_len = key.len() as u16 | TTL_FLAG;
+                out[..2].copy_from_slice(&u16_as_u8_le(key_len));
+                out.splice(2..2, ms.to_le_bytes().iter().cloned());
+            }
+            out
+        });
+        async move {
+            let mut conn = result?;
+            let to_send = write_result?;
+            conn.write(&to_send)?;
+            Ok(())
+        }
+    }
+
+    /// Get a key
+    pub fn get(&self, key: &[u8]) -> impl Future<Output = Result<Vec<u8>, Error>> {
+        let result = spawn_and_send(&self.path("get"), key);
+        async move {
+            let mut conn = result?;
+            conn.await?;
+            let mut out = Vec::new();
+            conn.read_to_end(&mut out)?;
+            Ok(out)
+        }
+    }
+
+    /// Delete a key. Deleting a key that doesn't exist is not an error
+    pub fn delete(&self, key: &[u8]) -> impl Future<Output = Result<(), Error>> {
+        let result = spawn_and_send(&self.path("delete"), key);
+        async move {
+            let conn = result?;
+            conn.await?;
+            Ok(())
+        }
+    }
+
+    /// Check if a key exists
+    pub fn exists(&self, key: &[u8]) -> impl Future<Output = Result<bool, Error>> {
+        let result = spawn_and_send(&self.path("exists"), key);
+        async move {
+            let mut conn = result?;
+            conn.await?;
+            let mut out = Vec::new();
+            conn.read_to_end(&mut out)?;
+            Ok(out == [1])
+        }
+    }
+
+    /// Get keys and values that start with a prefix, in key order. A limit of 0
+    /// returns every matching key
+    pub fn scan(
+        &self,
+        prefix: &[u8],
+        limit: u32,
+    ) -> impl Future<Output = Result<Vec<(Vec<u8>, Vec<u8>)>, Error>> {
+        let result = write_key_and_value(prefix, &limit.to_le_bytes())
+            .and_then(|payload| spawn_and_send(&self.path("scan"), &payload));
+        async move {
+            let mut conn = result?;
+            conn.await?;
+            let mut out = Vec::new();
+            conn.read_to_end(&mut out)?;
+            read_key_values(&out)
+        }
+    }
+
+    /// Set a key to a value only if its current value is equal to `old`. If `old`
+    /// is `None` the value is only set if the key doesn't exist. Returns true if
+    /// the value was set
+    pub fn cas(
+        &self,
+        key: &[u8],
+        old: Option<&[u8]>,
+        value: &[u8],
+    ) -> impl Future<Output = Result<bool, Error>> {
+        let mut rest = match old {
+            Some(old) => {
+                let mut rest = (old.len() as u32).to_le_bytes().to_vec();
+                rest.extend_from_slice(old);
+                rest
+            }
+            None => std::u32::MAX.to_le_bytes().to_vec(),
+        };
+        rest.extend_from_slice(value);
+        let result = write_key_and_value(key, &rest)
+            .and_then(|payload| spawn_and_send(&self.path("cas"), &payload));
+        async move {
+            let mut conn = result?;
+            conn.await?;
+            let mut out = Vec::new();
+            conn.read_to_end(&mut out)?;
+            Ok(out == [1])
+        }
+    }
+}
+
+fn read_key_values(mut input: &[u8]) -> Result<Vec<(Vec<u8>, Vec<u8>)>, Error> {
+    let mut pairs = Vec::new();
+    while !input.is_empty() {
+        if input.len() < 4 {
+            return Err(err_msg("invalid input length for key/value list"));
+        }
+        let ln = u32::from_le_bytes([input[0], input[1], input[2], input[3]]) as usize;
+        input = &input[4..];
+        if ln > input.len() || ln < 2 {
+            return Err(err_msg("key/value length is longer than input"));
+        }
+        let key_len = u16::from_le_bytes([input[0], input[1]]) as usize;
+        if key_len + 2 > ln {
+            return Err(err_msg("key length is longer than input"));
+        }
+        pairs.push((
+            input[2..key_len + 2].to_vec(),
+            input[key_len + 2..ln].to_vec(),
+        ));
+        input = &input[ln..];
+    }
+    Ok(pairs)
+}
+
 fn u16_as_u8_le(x: u16) -> [u8; 2] {
     [(x & 0xff) as u8, ((x >> 8) & 0xff) as u8]
 }
 
+// set on the key length of a set request that is followed by a ttl
+const TTL_FLAG: u16 = 0x8000;
+
 fn write_key_and_value(key: &[u8], value: &[u8]) -> Result<Vec<u8>, Error> {
     if key.len() > 10_000 {
         return Err(err_msg("key values can't be larger than 10,000"));
@@ -35,7 +35,10 @@ fn main() -> Result<()> {
         env::var("EMBLY_ADDR").expect("EMBLY_ADDR environment variable should be available");
     let embly_module =
         env::var("EMBLY_MODULE").expect("EMBLY_MODULE environment variable should be available");
-    let master_socket = UnixStream::connect("/tmp/embly.sock")?;
+    // the master passes its socket, each project has its own
+    let socket_path =
+        env::var("EMBLY_SOCKET").expect("EMBLY_SOCKET environment variable should be available");
+    let master_socket = UnixStream::connect(socket_path)?;
     let mut instance = Instance::new(embly_module, addr_string, master_socket)?;
 
     let exit_code = match instance
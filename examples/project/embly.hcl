@@ -22,3 +22,9 @@ gateway {
   port     = 8082
   function = "${function.listener}"
 }
+
+gateway {
+  type     = "tcp"
+  port     = 8083
+  function = "${function.echo}"
+}
@@ -29,9 +29,13 @@ type Builder struct {
 	ProjectRoot string
 	ui          cli.Ui
 	Functions   map[string]Files
+
+	// OnRebuild is called after a function has been rebuilt while watching for changes
+	OnRebuild func(fn config.Function)
 }
 
-func (builder *Builder) emblyBuildDir() string {
+// EmblyBuildDir is the directory that build output and local state is written to
+func (builder *Builder) EmblyBuildDir() string {
 	return filepath.Join(builder.ProjectRoot, "embly_build")
 }
 
@@ -131,6 +135,7 @@ func (builder *Builder) WatchForChangesAndRebuild() (err error) {
 			if !isBuilding[i] {
 				isBuilding[i] = true
 				builder.ui.Info("rebuilding function")
+				i := i
 				go func() {
 					if err := builder.build(builder.Config.Functions[i]); err != nil {
 						fmt.Printf("%+v", err)
@@ -139,6 +144,9 @@ func (builder *Builder) WatchForChangesAndRebuild() (err error) {
 					}
 					isBuilding[i] = false
 					builder.ui.Info("rebuilding complete")
+					if builder.OnRebuild != nil {
+						builder.OnRebuild(builder.Config.Functions[i])
+					}
 				}()
 				// build
 			}
@@ -165,7 +173,7 @@ func (builder *Builder) WatchForChangesAndRebuild() (err error) {
 }
 
 func (builder *Builder) initBuildDirectory() (err error) {
-	emblyBuildDir := builder.emblyBuildDir()
+	emblyBuildDir := builder.EmblyBuildDir()
 	ebFileInfo, _ := os.Stat(emblyBuildDir)
 	if ebFileInfo == nil {
 		if err = os.Mkdir(emblyBuildDir, os.ModePerm); err != nil {
@@ -200,7 +208,7 @@ func (builder *Builder) CompileWasmToObject(isTar bool) (err error) {
 	if isTar {
 
 		for _, fn := range builder.Config.Functions {
-			objLocation := filepath.Join(builder.emblyBuildDir(), fn.Name+"."+builder.objectExtension())
+			objLocation := filepath.Join(builder.EmblyBuildDir(), fn.Name+"."+builder.objectExtension())
 			_, err := os.Stat(objLocation)
 			if err == nil {
 				builder.addObjFile(fn.Name, objLocation)
@@ -267,8 +275,8 @@ func (builder *Builder) objectExtension() string {
 func (builder *Builder) compileWasm(fn config.Function) (err error) {
 	builder.ui.Output(fmt.Sprintf("Compiling %s.wasm to a local object file", fn.Name))
 	start := time.Now()
-	wasmFile := filepath.Join(builder.emblyBuildDir(), fn.Name+".wasm")
-	objLocation := filepath.Join(builder.emblyBuildDir(), fn.Name+"."+builder.objectExtension())
+	wasmFile := filepath.Join(builder.EmblyBuildDir(), fn.Name+".wasm")
+	objLocation := filepath.Join(builder.EmblyBuildDir(), fn.Name+"."+builder.objectExtension())
 	if err = lucet.CompileWasmToObject(wasmFile, objLocation); err != nil {
 		return
 	}
@@ -286,11 +294,11 @@ func (builder *Builder) compileFunction(fn config.Function) (err error) {
 		Sources:        fn.Sources,
 		BuildLocation:  fn.Path,
 		ProjectRoot:    builder.ProjectRoot,
-		DestinationDir: builder.emblyBuildDir(),
+		DestinationDir: builder.EmblyBuildDir(),
 	}); err != nil {
 		return
 	}
-	wasmFile := filepath.Join(builder.emblyBuildDir(), fn.Name+".wasm")
+	wasmFile := filepath.Join(builder.EmblyBuildDir(), fn.Name+".wasm")
 	builder.addWasmFile(fn.Name, wasmFile)
 	return
 }
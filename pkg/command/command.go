@@ -70,6 +70,8 @@ func createCLI() *cli.CLI {
 		"run":         wrap(&runCommand{}),
 		"bundle":      wrap(&bundleCommand{}),
 		"build":       wrap(&buildCommand{}),
+		"ps":          wrap(&psCommand{}),
+		"logs":        wrap(&logsCommand{}),
 		"db":          factory(&dbCommand{}),
 		"db delete":   wrapSimple(dbDeleteCommand),
 		"db validate": wrapSimple(dbValidateCommand),
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"embly/pkg/config"
+	"embly/pkg/core"
+
+	"github.com/pkg/errors"
+)
+
+// projectSockAddr is the socket of the project that commands like embly ps talk
+// to. It's socket if that's set, then EMBLY_SOCKET, then the socket of the
+// project in the current directory
+func projectSockAddr(socket string) (string, error) {
+	if socket != "" {
+		return socket, nil
+	}
+	if socket = os.Getenv("EMBLY_SOCKET"); socket != "" {
+		return socket, nil
+	}
+	f, location, err := config.FindConfigFile("")
+	if err != nil {
+		return "", errors.Wrap(err, "pass --socket to connect to a project elsewhere")
+	}
+	f.Close()
+	return core.ProjectSockAddr(location), nil
+}
+
+// controlGet requests path from the admin api of a running embly run or
+// embly dev over the control socket next to socket. The caller must close the
+// response body
+func controlGet(socket, path string) (resp *http.Response, err error) {
+	sockAddr, err := projectSockAddr(socket)
+	if err != nil {
+		return nil, err
+	}
+	addr := core.ControlSockAddr(sockAddr)
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		},
+	}}
+	// the host is ignored, requests always go to the socket
+	resp, err = client.Get("http://embly" + path)
+	if err != nil {
+		return nil, errors.Errorf(
+			"couldn't connect to embly at %s, is embly run or embly dev running?", addr)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, errors.New(strings.TrimSpace(fmt.Sprintf("%s: %s", resp.Status, body)))
+	}
+	return resp, nil
+}
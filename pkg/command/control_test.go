@@ -0,0 +1,124 @@
+package command
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"embly/pkg/core"
+	"embly/pkg/tester"
+
+	"github.com/mitchellh/cli"
+)
+
+// fakeControlSocket serves handler on the control socket next to a temporary
+// socket
+func fakeControlSocket(t tester.Tester, handler http.Handler) (ui *cli.MockUi, socket string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	oldUI := UI
+	socket = filepath.Join(dir, "embly.sock")
+	ui = cli.NewMockUi()
+	UI = ui
+	var server *http.Server
+	if handler != nil {
+		listener, err := net.Listen("unix", core.ControlSockAddr(socket))
+		t.PanicOnErr(err)
+		server = &http.Server{Handler: handler}
+		go server.Serve(listener)
+	}
+	return ui, socket, func() {
+		if server != nil {
+			server.Close()
+		}
+		UI = oldUI
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPsCommand(te *testing.T) {
+	t := tester.New(te)
+	ui, socket, cleanup := fakeControlSocket(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Assert().Equal("/instances", r.URL.Path)
+		w.Write([]byte(`[
+			{"type": "function", "addr": "12", "parent": "7", "name": "function.foo",
+			 "pid": 99, "status": "running", "uptime_seconds": 61.2},
+			{"type": "gateway", "addr": "7", "child": "12", "name": "gateway", "uptime_seconds": 62}
+		]`))
+	}))
+	defer cleanup()
+
+	t.Assert().Equal(0, (&wrapper{&psCommand{}}).Run([]string{"--socket", socket}))
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	t.Assert().Len(lines, 2)
+	t.Assert().Equal(strings.Fields("ADDR TYPE NAME PARENT PID STATUS UPTIME"), strings.Fields(lines[0]))
+	t.Assert().Equal(strings.Fields("12 function function.foo 7 99 running 1m1s"), strings.Fields(lines[1]))
+
+	ui.OutputWriter.Reset()
+	t.Assert().Equal(0, (&wrapper{&psCommand{}}).Run([]string{"-a", "--socket", socket}))
+	lines = strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	t.Assert().Len(lines, 3)
+	t.Assert().Equal(strings.Fields("7 gateway gateway - - - 1m2s"), strings.Fields(lines[2]))
+}
+
+func TestLogsCommand(te *testing.T) {
+	t := tester.New(te)
+	ui, socket, cleanup := fakeControlSocket(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Assert().Equal("/logs", r.URL.Path)
+		t.Assert().Equal("foo", r.URL.Query().Get("function"))
+		t.Assert().Equal("5", r.URL.Query().Get("lines"))
+		t.Assert().Equal("false", r.URL.Query().Get("follow"))
+		w.Write([]byte(`{"function": "function.foo", "stream": "stdout", "message": "hello"}
+{"function": "function.foo", "stream": "stderr", "message": "oh no"}
+`))
+	}))
+	defer cleanup()
+
+	t.Assert().Equal(0, (&wrapper{&logsCommand{}}).Run([]string{"-n", "5", "--no-follow", "--socket", socket, "foo"}))
+	t.Assert().Equal("[function.foo]: hello\n", ui.OutputWriter.String())
+	t.Assert().Equal("[function.foo]: oh no\n", ui.ErrorWriter.String())
+}
+
+func TestControlNotRunning(te *testing.T) {
+	t := tester.New(te)
+	_, socket, cleanup := fakeControlSocket(t, nil)
+	defer cleanup()
+	_, err := controlGet(socket, "/instances")
+	t.ErrorContains(err, "is embly run or embly dev running?")
+}
+
+func TestProjectSockAddr(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+	// the working directory has symlinks resolved
+	dir, err = filepath.EvalSymlinks(dir)
+	t.PanicOnErr(err)
+	t.PanicOnErr(ioutil.WriteFile(filepath.Join(dir, "embly.hcl"), nil, 0644))
+	t.PanicOnErr(os.Mkdir(filepath.Join(dir, "hello"), 0755))
+	wd, err := os.Getwd()
+	t.PanicOnErr(err)
+	defer os.Chdir(wd)
+	t.PanicOnErr(os.Chdir(filepath.Join(dir, "hello")))
+	defer os.Setenv("EMBLY_SOCKET", os.Getenv("EMBLY_SOCKET"))
+	os.Unsetenv("EMBLY_SOCKET")
+
+	// the project is found from a subdirectory
+	sockAddr, err := projectSockAddr("")
+	t.PanicOnErr(err)
+	t.Assert().Equal(core.ProjectSockAddr(dir), sockAddr)
+
+	os.Setenv("EMBLY_SOCKET", "/tmp/env.sock")
+	sockAddr, err = projectSockAddr("")
+	t.PanicOnErr(err)
+	t.Assert().Equal("/tmp/env.sock", sockAddr)
+
+	sockAddr, err = projectSockAddr("/tmp/flag.sock")
+	t.PanicOnErr(err)
+	t.Assert().Equal("/tmp/flag.sock", sockAddr)
+}
@@ -11,11 +11,19 @@ import (
 type devCommand struct {
 	flagSet   *flag.FlagSet
 	dontWatch *bool
+	logFormat *string
+	adminAddr *string
+	socket    *string
+	trace     traceFlags
 }
 
 func (f *devCommand) flags() *flag.FlagSet {
 	f.flagSet = &flag.FlagSet{}
 	f.dontWatch = f.flagSet.BoolP("dont-watch", "d", false, "Disable watching for changes on local files and rebuilding")
+	f.logFormat = logFormatFlag(f.flagSet)
+	f.adminAddr = adminAddrFlag(f.flagSet)
+	f.socket = socketFlag(f.flagSet)
+	f.trace.add(f.flagSet)
 	return f.flagSet
 }
 func (f *devCommand) synopsis() string {
@@ -59,8 +67,14 @@ func (f *devCommand) run(args []string) (err error) {
 		return nil
 	}
 	if err := core.Start(builder, UI, core.StartConfig{
-		Watch: !*f.dontWatch,
-		Dev:   true,
+		Watch:     !*f.dontWatch,
+		Dev:       true,
+		LogFormat: *f.logFormat,
+		AdminAddr: *f.adminAddr,
+		Socket:    *f.socket,
+
+		TraceEndpoint: *f.trace.endpoint,
+		TraceFile:     *f.trace.file,
 	}); err != nil {
 		return err
 	}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+)
+
+type logsCommand struct {
+	flagSet  *flag.FlagSet
+	lines    *int
+	noFollow *bool
+	socket   *string
+}
+
+func (f *logsCommand) flags() *flag.FlagSet {
+	f.flagSet = &flag.FlagSet{}
+	f.lines = f.flagSet.IntP("lines", "n", 20, "number of recent lines to show")
+	f.noFollow = f.flagSet.Bool("no-follow", false, "exit after showing recent lines")
+	f.socket = socketFlag(f.flagSet)
+	return f.flagSet
+}
+
+func (f *logsCommand) synopsis() string {
+	return "Show the output of running functions"
+}
+
+func (f *logsCommand) help() string {
+	return `
+Usage: embly logs [options] [<function>]
+
+	Show the stdout and stderr of the functions of a running embly run or
+	embly dev and follow new output. Pass a function name to only show the
+	output of that function. The project in the current directory is used
+	unless --socket is passed.`
+}
+
+// outputLine is a line of function output from the admin api
+type outputLine struct {
+	Function string `json:"function"`
+	Stream   string `json:"stream"`
+	Message  string `json:"message"`
+}
+
+func (f *logsCommand) run(args []string) (err error) {
+	if len(args) > 1 {
+		return errors.New("error: embly logs takes only one positional argument")
+	}
+	if *f.lines < 0 {
+		return errors.New("error: --lines can't be negative")
+	}
+	query := url.Values{}
+	if len(args) == 1 {
+		query.Set("function", args[0])
+	}
+	query.Set("lines", strconv.Itoa(*f.lines))
+	query.Set("follow", strconv.FormatBool(!*f.noFollow))
+
+	resp, err := controlGet(*f.socket, "/logs?"+query.Encode())
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line outputLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return errors.Wrap(err, "error reading logs")
+		}
+		text := fmt.Sprintf("[%s]: %s", line.Function, line.Message)
+		if line.Stream == "stderr" {
+			UI.Error(text)
+		} else {
+			UI.Output(text)
+		}
+	}
+	return scanner.Err()
+}
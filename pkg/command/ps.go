@@ -0,0 +1,82 @@
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"embly/pkg/core"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+)
+
+type psCommand struct {
+	flagSet *flag.FlagSet
+	all     *bool
+	socket  *string
+}
+
+func (f *psCommand) flags() *flag.FlagSet {
+	f.flagSet = &flag.FlagSet{}
+	f.all = f.flagSet.BoolP("all", "a", false, "also list gateways, kv commands and vinyl connections")
+	f.socket = socketFlag(f.flagSet)
+	return f.flagSet
+}
+
+func (f *psCommand) synopsis() string {
+	return "List running function instances"
+}
+
+func (f *psCommand) help() string {
+	return `
+Usage: embly ps [options]
+
+	List the function instances of a running embly run or embly dev. The
+	project in the current directory is used unless --socket is passed.`
+}
+
+func (f *psCommand) run(args []string) (err error) {
+	if len(args) > 0 {
+		return errors.New("error: embly ps takes no positional arguments")
+	}
+	resp, err := controlGet(*f.socket, "/instances")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	instances := []core.Instance{}
+	if err = json.NewDecoder(resp.Body).Decode(&instances); err != nil {
+		return errors.Wrap(err, "error reading instances")
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDR\tTYPE\tNAME\tPARENT\tPID\tSTATUS\tUPTIME")
+	for _, inst := range instances {
+		if inst.Type != "function" && !*f.all {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			inst.Addr, inst.Type, inst.Name,
+			orDash(inst.Parent, inst.Parent != 0),
+			orDash(inst.Pid, inst.Pid != 0),
+			orDash(inst.Status, inst.Status != ""),
+			orDash(time.Duration(inst.Uptime*float64(time.Second)).Round(time.Second), inst.Uptime != 0),
+		)
+	}
+	w.Flush()
+	UI.Output(strings.TrimSuffix(buf.String(), "\n"))
+	return nil
+}
+
+// orDash formats v, or a dash if it isn't set
+func orDash(v interface{}, set bool) string {
+	if !set {
+		return "-"
+	}
+	return fmt.Sprint(v)
+}
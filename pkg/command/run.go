@@ -3,6 +3,7 @@ package command
 import (
 	"embly/pkg/build"
 	"embly/pkg/core"
+	"fmt"
 	"os"
 
 	"github.com/pkg/errors"
@@ -10,15 +11,56 @@ import (
 )
 
 type runCommand struct {
-	flagSet *flag.FlagSet
-	host    *string
+	flagSet   *flag.FlagSet
+	host      *string
+	logFormat *string
+	adminAddr *string
+	socket    *string
+	trace     traceFlags
 }
 
 func (f *runCommand) flags() *flag.FlagSet {
 	f.flagSet = &flag.FlagSet{}
 	f.host = f.flagSet.String("host", "", "set the host to broadcast on")
+	f.logFormat = logFormatFlag(f.flagSet)
+	f.adminAddr = adminAddrFlag(f.flagSet)
+	f.socket = socketFlag(f.flagSet)
+	f.trace.add(f.flagSet)
 	return f.flagSet
 }
+
+// logFormatFlag adds the --log-format flag shared by run and dev
+func logFormatFlag(flagSet *flag.FlagSet) *string {
+	return flagSet.String("log-format", core.LogFormatText,
+		fmt.Sprintf(`log output format, "%s" or "%s" for one json object per line`, core.LogFormatText, core.LogFormatJSON))
+}
+
+// adminAddrFlag adds the --admin-addr flag shared by run and dev
+func adminAddrFlag(flagSet *flag.FlagSet) *string {
+	return flagSet.String("admin-addr", "",
+		`address for the admin listener that serves metrics, like "127.0.0.1:9090"`)
+}
+
+// socketFlag adds the --socket flag shared by the commands that run or talk to
+// a project
+func socketFlag(flagSet *flag.FlagSet) *string {
+	return flagSet.String("socket", "",
+		"unix socket that functions connect to, defaults to a socket for the project in "+core.SockDir)
+}
+
+// traceFlags are the --trace-endpoint and --trace-file flags shared by run and
+// dev
+type traceFlags struct {
+	endpoint *string
+	file     *string
+}
+
+func (tf *traceFlags) add(flagSet *flag.FlagSet) {
+	tf.endpoint = flagSet.String("trace-endpoint", "",
+		`url of an OTLP collector to send traces to, like "http://localhost:4318"`)
+	tf.file = flagSet.String("trace-file", "", "file to append traces to as json")
+}
+
 func (f *runCommand) synopsis() string {
 	return "Run a local embly project"
 }
@@ -77,8 +119,14 @@ func (f *runCommand) run(args []string) (err error) {
 		return nil
 	}
 	if err := core.Start(builder, UI, core.StartConfig{
-		Watch: false,
-		Host:  *f.host,
+		Watch:     false,
+		Host:      *f.host,
+		LogFormat: *f.logFormat,
+		AdminAddr: *f.adminAddr,
+		Socket:    *f.socket,
+
+		TraceEndpoint: *f.trace.endpoint,
+		TraceFile:     *f.trace.file,
 	}); err != nil {
 		return err
 	}
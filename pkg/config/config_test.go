@@ -1,7 +1,9 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseConfig(t *testing.T) {
@@ -13,3 +15,294 @@ func TestParseConfig(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestAllowUnknownDatabase(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader(`
+function "foo" {
+  runtime = "rust"
+  path    = "./foo"
+  allow {
+    databases = ["nope"]
+  }
+}
+`))
+	if err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Error("unknown database should be an error", err)
+	}
+}
+
+func TestKVNamespaces(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`
+kv "sessions" {
+  quota = 1000
+}
+function "foo" {
+  runtime = "rust"
+  path    = "./foo"
+  allow {
+    kv = ["default", "sessions"]
+  }
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	namespaces := cfg.KVNamespaces()
+	if len(namespaces) != 2 || namespaces[0].Name != "default" || namespaces[1].Quota != 1000 {
+		t.Error("unexpected namespaces", namespaces)
+	}
+
+	_, err = ParseConfig(strings.NewReader(`
+function "foo" {
+  runtime = "rust"
+  path    = "./foo"
+  allow {
+    kv = ["nope"]
+  }
+}
+`))
+	if err == nil || !strings.Contains(err.Error(), "doesn't exist") {
+		t.Error("unknown kv namespace should be an error", err)
+	}
+
+	_, err = ParseConfig(strings.NewReader(`
+kv "a" {}
+kv "a" {}
+`))
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Error("duplicate kv namespace should be an error", err)
+	}
+}
+
+func TestGatewayTimeouts(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`
+gateway {
+  type    = "http"
+  timeout = "30s"
+  route "/slow" {
+    timeout = "2m"
+  }
+  route "/" {}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := cfg.Gateways[0]
+	if d := g.RouteTimeout(g.Routes[0]); d != 2*time.Minute {
+		t.Error("route timeout should override the gateway timeout", d)
+	}
+	if d := g.RouteTimeout(g.Routes[1]); d != 30*time.Second {
+		t.Error("route should use the gateway timeout", d)
+	}
+
+	_, err = ParseConfig(strings.NewReader(`
+gateway {
+  type    = "http"
+  timeout = "soon"
+}
+`))
+	if err == nil || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Error("invalid timeout should be an error", err)
+	}
+}
+
+func TestRouteValidation(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader(`
+gateway {
+  type = "http"
+  route "/users/:id/posts/:post" {
+    methods = ["GET", "POST"]
+    host    = "api.example.com"
+  }
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, route := range []string{`"/users/:id/:id"`, `"/users/:"`, `"users"`} {
+		_, err := ParseConfig(strings.NewReader(`
+gateway {
+  type = "http"
+  route ` + route + ` {}
+}
+`))
+		if err == nil {
+			t.Error("route should be invalid", route)
+		}
+	}
+}
+
+func TestGatewayTLS(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`
+gateway {
+  type = "http"
+  tls {
+    cert = "./certs/cert.pem"
+    key  = "./certs/key.pem"
+  }
+}
+gateway {
+  type = "http"
+  tls {}
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Gateways[0].TLS.Local() || !cfg.Gateways[1].TLS.Local() {
+		t.Error("only a tls block without a cert and key should be local")
+	}
+
+	for _, gateway := range []string{
+		`type = "http"
+  tls {
+    cert = "./cert.pem"
+  }`,
+		`type = "tcp"
+  tls {}`,
+		`type = "http"
+  trusted_proxies = ["10.0.0.0/8", "proxy"]`,
+	} {
+		_, err := ParseConfig(strings.NewReader("gateway {\n  " + gateway + "\n}\n"))
+		if err == nil {
+			t.Error("gateway should be invalid", gateway)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`
+gateway {
+  type = "http"
+  middleware {
+    cors {
+      origins = ["*"]
+    }
+    rate_limit {
+      requests = 100
+      per      = "1m"
+    }
+  }
+  route "/admin/" {
+    middleware {
+      basic_auth {
+        users = { admin = "secret" }
+      }
+      rate_limit {
+        requests = 10
+      }
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := cfg.Gateways[0]
+	mw := g.RouteMiddleware(g.Routes[0])
+	if mw.CORS == nil || mw.BasicAuth.Users["admin"] != "secret" {
+		t.Error("route middleware should be added to the gateway middleware", mw)
+	}
+	if d := mw.RateLimit.Interval(); d != 100*time.Millisecond {
+		t.Error("route rate limit should replace the gateway rate limit", d)
+	}
+	if d := g.RouteMiddleware(GatewayRoute{}).RateLimit.Interval(); d != 600*time.Millisecond {
+		t.Error("gateway rate limit should be used", d)
+	}
+
+	for _, middleware := range []string{
+		`ip {
+      allow = ["10.0.0.0/33"]
+    }`,
+		`rate_limit {
+      requests = 0
+    }`,
+		`cors {
+      origins     = ["*"]
+      credentials = true
+    }`,
+		`bearer_auth {
+      tokens = []
+    }`,
+	} {
+		_, err := ParseConfig(strings.NewReader(`
+gateway {
+  type = "http"
+  middleware {
+    ` + middleware + `
+  }
+}
+`))
+		if err == nil {
+			t.Error("middleware should be invalid", middleware)
+		}
+	}
+}
+
+func TestRouteAuth(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`
+function "auth" {
+  runtime = "rust"
+  path    = "./auth"
+}
+gateway {
+  type = "http"
+  route "/account/" {
+    files        = "${files.account}"
+    auth         = "${function.auth}"
+    auth_headers = ["X-User-Id"]
+  }
+}
+files "account" {
+  path = "./account"
+}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth := cfg.Gateways[0].Routes[0].Auth; auth != "function.auth" {
+		t.Error("auth should reference the function", auth)
+	}
+	if headers := cfg.Gateways[0].Routes[0].AuthHeaders; len(headers) != 1 || headers[0] != "X-User-Id" {
+		t.Error("auth headers should be parsed", headers)
+	}
+
+	_, err = ParseConfig(strings.NewReader(`
+gateway {
+  type = "http"
+  route "/account/" {
+    files        = "${files.account}"
+    auth_headers = ["X-User-Id"]
+  }
+}
+files "account" {
+  path = "./account"
+}
+`))
+	if err == nil {
+		t.Error("auth_headers should need an auth function")
+	}
+
+	for _, auth := range []string{`"auth"`, `"function.missing"`, `"${files.account}"`} {
+		_, err := ParseConfig(strings.NewReader(`
+function "auth" {
+  runtime = "rust"
+  path    = "./auth"
+}
+gateway {
+  type = "http"
+  route "/account/" {
+    files = "${files.account}"
+    auth  = ` + auth + `
+  }
+}
+files "account" {
+  path = "./account"
+}
+`))
+		if err == nil {
+			t.Error("auth should be invalid", auth)
+		}
+	}
+}
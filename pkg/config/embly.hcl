@@ -12,6 +12,11 @@ function "encoder" {
   runtime = "rust"
 
   # service_definition = "${schema.proto.EncoderService}"
+
+  allow {
+    functions = ["${function.encoder}"]
+    databases = ["main"]
+  }
 }
 
 
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Middleware is the built-in request handling that runs in the http gateway
+// before a request reaches a route. Each block is optional, a route's blocks
+// replace the gateway's blocks of the same kind
+type Middleware struct {
+	CORS       *CORS       `hcl:"cors,block"`
+	Compress   *Compress   `hcl:"compress,block"`
+	BasicAuth  *BasicAuth  `hcl:"basic_auth,block"`
+	BearerAuth *BearerAuth `hcl:"bearer_auth,block"`
+	IP         *IPFilter   `hcl:"ip,block"`
+	RateLimit  *RateLimit  `hcl:"rate_limit,block"`
+}
+
+// CORS answers preflight requests and adds cross origin headers to responses.
+// An origin of "*" allows every origin
+type CORS struct {
+	Origins       []string `hcl:"origins"`
+	Methods       []string `hcl:"methods,optional"`
+	Headers       []string `hcl:"headers,optional"`
+	ExposeHeaders []string `hcl:"expose_headers,optional"`
+	Credentials   bool     `hcl:"credentials,optional"`
+	// MaxAge is how long in seconds browsers can cache a preflight response
+	MaxAge int `hcl:"max_age,optional"`
+}
+
+// Compress gzips responses for clients that accept it. Types are the content
+// types that are compressed, the defaults are common text formats
+type Compress struct {
+	Types []string `hcl:"types,optional"`
+}
+
+// BasicAuth requires a username and password from Users
+type BasicAuth struct {
+	Realm string            `hcl:"realm,optional"`
+	Users map[string]string `hcl:"users"`
+}
+
+// BearerAuth requires an Authorization header with one of Tokens
+type BearerAuth struct {
+	Tokens []string `hcl:"tokens"`
+}
+
+// IPFilter limits the client addresses that can make requests. Addresses can
+// be single ips or cidr ranges. Deny is checked first, and if Allow is empty
+// every address that isn't denied is allowed
+type IPFilter struct {
+	Allow []string `hcl:"allow,optional"`
+	Deny  []string `hcl:"deny,optional"`
+}
+
+// RateLimit limits the number of requests each client address can make.
+// Requests are allowed every Per divided by Requests, with bursts of up to
+// Burst requests. Per defaults to one second and Burst defaults to Requests
+type RateLimit struct {
+	Requests int    `hcl:"requests"`
+	Per      string `hcl:"per,optional"`
+	Burst    int    `hcl:"burst,optional"`
+}
+
+// Interval returns how long it takes for one request to be allowed again
+func (rl RateLimit) Interval() time.Duration {
+	// checked by ParseConfig
+	per, _ := parseTimeout(rl.Per)
+	if per == 0 {
+		per = time.Second
+	}
+	return per / time.Duration(rl.Requests)
+}
+
+// RouteMiddleware returns the middleware for a route. Pass an empty route for
+// the gateway's function
+func (g Gateway) RouteMiddleware(route GatewayRoute) (mw Middleware) {
+	if g.Middleware != nil {
+		mw = *g.Middleware
+	}
+	override := route.Middleware
+	if override == nil {
+		return
+	}
+	if override.CORS != nil {
+		mw.CORS = override.CORS
+	}
+	if override.Compress != nil {
+		mw.Compress = override.Compress
+	}
+	if override.BasicAuth != nil {
+		mw.BasicAuth = override.BasicAuth
+	}
+	if override.BearerAuth != nil {
+		mw.BearerAuth = override.BearerAuth
+	}
+	if override.IP != nil {
+		mw.IP = override.IP
+	}
+	if override.RateLimit != nil {
+		mw.RateLimit = override.RateLimit
+	}
+	return
+}
+
+// ParseCIDR parses an ip or cidr range from an ip filter
+func ParseCIDR(addr string) (*net.IPNet, error) {
+	if !strings.Contains(addr, "/") {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, errors.Errorf(`"%s" is not an ip address or cidr range`, addr)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(addr)
+	if err != nil {
+		return nil, errors.Errorf(`"%s" is not an ip address or cidr range`, addr)
+	}
+	return ipNet, nil
+}
+
+func (mw *Middleware) validate() error {
+	if mw == nil {
+		return nil
+	}
+	if mw.CORS != nil && len(mw.CORS.Origins) == 0 {
+		return errors.New("cors needs at least one origin")
+	}
+	if mw.CORS != nil && mw.CORS.Credentials && contains(mw.CORS.Origins, "*") {
+		return errors.New(`cors can't allow credentials for the origin "*"`)
+	}
+	if mw.BasicAuth != nil && len(mw.BasicAuth.Users) == 0 {
+		return errors.New("basic_auth needs at least one user")
+	}
+	if mw.BearerAuth != nil && len(mw.BearerAuth.Tokens) == 0 {
+		return errors.New("bearer_auth needs at least one token")
+	}
+	if mw.IP != nil {
+		for _, addr := range append(append([]string{}, mw.IP.Allow...), mw.IP.Deny...) {
+			if _, err := ParseCIDR(addr); err != nil {
+				return err
+			}
+		}
+	}
+	if rl := mw.RateLimit; rl != nil {
+		if rl.Requests <= 0 || rl.Burst < 0 {
+			return errors.New("rate_limit requests must be positive and burst can't be negative")
+		}
+		per, err := parseTimeout(rl.Per)
+		if err != nil {
+			return err
+		}
+		if per != 0 && per < time.Duration(rl.Requests) {
+			return errors.Errorf(`rate_limit of %d requests per "%s" is too fast`, rl.Requests, rl.Per)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	comms_proto "embly/pkg/core/proto"
+
+	"github.com/pkg/errors"
+)
+
+// killedExitCode is the exit code reported for functions that were killed,
+// it's what a shell reports for a process killed with SIGKILL
+const killedExitCode = 128 + 9
+
+// adminHandler serves the admin listener's endpoints
+func (master *Master) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", master.metrics.registry.Handler())
+	mux.HandleFunc("/instances", master.adminInstances)
+	mux.HandleFunc("/instances/", master.adminInstance)
+	mux.HandleFunc("/functions", master.adminFunctions)
+	mux.HandleFunc("/databases", master.adminDatabases)
+	mux.HandleFunc("/logs", master.adminLogs)
+	return mux
+}
+
+// launchAdminServer starts the admin listener. It's kept separate from the
+// gateways so that it can be bound to a private address
+func (master *Master) launchAdminServer(addr string) (err error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "error starting admin listener")
+	}
+	server := &http.Server{Handler: master.adminHandler()}
+	master.ui.Info(fmt.Sprintf("Admin listener on %s, metrics at /metrics", listener.Addr()))
+	master.addServer(server)
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			master.ui.Error(fmt.Sprintf("Admin listener on %s stopped: %s", addr, err))
+		}
+	}()
+	return nil
+}
+
+// launchControlServer serves the admin api on a unix socket next to the
+// master's socket so that commands like embly ps can find a running project.
+// It must be called after the master is listening so that the lock is held
+func (master *Master) launchControlServer() (err error) {
+	addr := ControlSockAddr(master.sockAddr)
+	if err := os.RemoveAll(addr); err != nil {
+		return errors.Wrap(err, "error removing old control socket")
+	}
+	listener, err := net.Listen("unix", addr)
+	if err != nil {
+		return errors.Wrap(err, "error starting control socket")
+	}
+	server := &http.Server{Handler: master.adminHandler()}
+	master.addServer(server)
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			master.ui.Error(fmt.Sprintf("Control socket at %s stopped: %s", addr, err))
+		}
+	}()
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// Instance describes a function, gateway, kv command or vinyl connection in
+// the master's registry. Parent is the address that spawned it, or that owns
+// it for kv and vinyl
+type Instance struct {
+	Type   string `json:"type"`
+	Addr   uint64 `json:"addr,string"`
+	Parent uint64 `json:"parent,omitempty,string"`
+	Child  uint64 `json:"child,omitempty,string"`
+	Name   string `json:"name"`
+	Module string `json:"module,omitempty"`
+	Pid    int    `json:"pid,omitempty"`
+	// Status is "starting", "running" or "exited" for functions
+	Status  string    `json:"status,omitempty"`
+	Started time.Time `json:"started"`
+	Uptime  float64   `json:"uptime_seconds"`
+}
+
+func functionStatus(fn *Function) string {
+	if fn.Exited() {
+		return "exited"
+	}
+	select {
+	case <-fn.connected:
+		return "running"
+	default:
+		return "starting"
+	}
+}
+
+// Instances lists everything in the registry, sorted by type and name
+func (m *Master) Instances() (instances []Instance) {
+	now := time.Now()
+	m.registry.Range(func(key, value interface{}) bool {
+		inst := Instance{Addr: key.(uint64), Name: receiverName(value.(funcOrGateway))}
+		switch fog := value.(type) {
+		case *Function:
+			inst.Type = "function"
+			inst.Parent = fog.parent
+			inst.Module = fog.startup.Module
+			inst.Status = functionStatus(fog)
+			inst.Started = fog.started
+			if fog.cmd.Process != nil {
+				inst.Pid = fog.cmd.Process.Pid
+			}
+		case *Gateway:
+			inst.Type = "gateway"
+			inst.Child = fog.child
+			inst.Started = fog.created
+		case *KV:
+			inst.Type = "kv"
+			inst.Parent = fog.owner
+		case *Vinyl:
+			inst.Type = "vinyl"
+			inst.Parent = fog.owner
+		}
+		if !inst.Started.IsZero() {
+			inst.Uptime = now.Sub(inst.Started).Seconds()
+		}
+		instances = append(instances, inst)
+		return true
+	})
+	sort.Slice(instances, func(i, j int) bool {
+		a, b := instances[i], instances[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Started.Before(b.Started)
+	})
+	return
+}
+
+// KillFunction stops the function at addr. Its parent is told that the function
+// exited so that gateways and functions waiting on it don't hang, idle pooled
+// functions are taken out of the pool first so that they can't be checked out
+func (m *Master) KillFunction(addr uint64) error {
+	fn, ok := m.getFuncOrGateway(addr).(*Function)
+	if !ok {
+		return errors.Errorf("no function found for address %d", addr)
+	}
+	if m.stopIdleFunction(fn) {
+		return nil
+	}
+	m.StopFunction(fn)
+	if parent := m.getFuncOrGateway(fn.parent); parent != nil {
+		parent.sendMsg(comms_proto.Message{
+			To:      fn.parent,
+			From:    addr,
+			Exiting: true,
+			Exit:    killedExitCode,
+		})
+	}
+	return nil
+}
+
+// adminInstances lists the registry
+func (master *Master) adminInstances(w http.ResponseWriter, r *http.Request) {
+	instances := master.Instances()
+	if instances == nil {
+		instances = []Instance{}
+	}
+	writeJSON(w, instances)
+}
+
+// adminInstance kills the function at /instances/<addr> with DELETE
+func (master *Master) adminInstance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	addr, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, "/instances/"), 10, 64)
+	if err != nil {
+		http.Error(w, "the address must be a number", http.StatusBadRequest)
+		return
+	}
+	if err := master.KillFunction(addr); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	master.ui.Info(fmt.Sprintf("Killed function %d from the admin api", addr))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminFunctions lists the registered function names and their modules
+func (master *Master) adminFunctions(w http.ResponseWriter, r *http.Request) {
+	type function struct {
+		Name   string `json:"name"`
+		Module string `json:"module"`
+	}
+	functions := []function{}
+	for name, module := range master.functions {
+		functions = append(functions, function{Name: name, Module: module})
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	writeJSON(w, functions)
+}
+
+// adminDatabases lists the configured databases
+func (master *Master) adminDatabases(w http.ResponseWriter, r *http.Request) {
+	type database struct {
+		Name       string `json:"name"`
+		Type       string `json:"type"`
+		Definition string `json:"definition"`
+		Port       int    `json:"port"`
+	}
+	databases := []database{}
+	for _, db := range master.databases {
+		databases = append(databases, database{
+			Name:       db.Name,
+			Type:       db.Type,
+			Definition: db.Definition,
+			Port:       db.Port,
+		})
+	}
+	sort.Slice(databases, func(i, j int) bool { return databases[i].Name < databases[j].Name })
+	writeJSON(w, databases)
+}
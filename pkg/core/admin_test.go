@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"embly/pkg/config"
+	"embly/pkg/tester"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestAdminAPI(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.ui = cli.NewMockUi()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.RegisterFunctionName("function.foo", "/build/foo.out")
+	m.RegisterFunctionName("function.bar", "/build/bar.out")
+	m.databases = map[string]config.Database{
+		"main": {Name: "main", Type: "vinyl", Definition: "./data.proto", Port: 8090},
+	}
+
+	f := startFakeFunction(t, m, "function.foo")
+	defer f.conn.Close()
+
+	w := serve(m.adminHandler(), httptest.NewRequest("GET", "/instances", nil))
+	t.Assert().Equal("application/json", w.Header().Get("Content-Type"))
+	instances := []Instance{}
+	t.PanicOnErr(json.Unmarshal(w.Body.Bytes(), &instances))
+	t.Assert().Len(instances, 2)
+	fn := instances[0]
+	t.Assert().Equal("function", fn.Type)
+	t.Assert().Equal(f.fn.addr, fn.Addr)
+	t.Assert().Equal(f.gat.ID, fn.Parent)
+	t.Assert().Equal("function.foo", fn.Name)
+	t.Assert().Equal("/build/foo.out", fn.Module)
+	t.Assert().Equal("running", fn.Status)
+	gat := instances[1]
+	t.Assert().Equal("gateway", gat.Type)
+	t.Assert().Equal(f.fn.addr, gat.Child)
+	t.Assert().True(gat.Uptime > 0)
+
+	w = serve(m.adminHandler(), httptest.NewRequest("GET", "/functions", nil))
+	t.Assert().JSONEq(`[
+		{"name": "function.bar", "module": "/build/bar.out"},
+		{"name": "function.foo", "module": "/build/foo.out"}
+	]`, w.Body.String())
+
+	w = serve(m.adminHandler(), httptest.NewRequest("GET", "/databases", nil))
+	t.Assert().JSONEq(`[{"name": "main", "type": "vinyl", "definition": "./data.proto", "port": 8090}]`, w.Body.String())
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", fmt.Sprintf("/instances/%d", f.fn.addr), http.StatusMethodNotAllowed},
+		{"DELETE", "/instances/foo", http.StatusBadRequest},
+		{"DELETE", fmt.Sprintf("/instances/%d", f.gat.ID), http.StatusNotFound},
+		{"DELETE", fmt.Sprintf("/instances/%d", f.fn.addr), http.StatusNoContent},
+		{"DELETE", fmt.Sprintf("/instances/%d", f.fn.addr), http.StatusNotFound},
+	} {
+		w = serve(m.adminHandler(), httptest.NewRequest(tc.method, tc.path, nil))
+		t.Assert().Equal(tc.status, w.Code, tc.method+" "+tc.path)
+	}
+
+	// the gateway was told its function exited, so reads don't hang
+	_, err := f.gat.Read(make([]byte, 1))
+	t.Assert().Equal(io.EOF, err)
+	t.Assert().Equal(int32(killedExitCode), f.gat.childExited)
+	t.Assert().Nil(m.getFuncOrGateway(f.fn.addr))
+}
+
+func TestControlSocket(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+	sockAddr := filepath.Join(dir, "embly.sock")
+	controlSockAddr := ControlSockAddr(sockAddr)
+	t.Assert().Equal(filepath.Join(dir, "embly-control.sock"), controlSockAddr)
+
+	m := NewMaster()
+	m.ui = cli.NewMockUi()
+	m.sockAddr = sockAddr
+	m.RegisterFunctionName("function.foo", "/build/foo.out")
+	t.PanicOnErr(m.launchControlServer())
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", controlSockAddr) },
+	}}
+	resp, err := client.Get("http://embly/functions")
+	t.PanicOnErr(err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	t.Assert().JSONEq(`[{"name": "function.foo", "module": "/build/foo.out"}]`, string(body))
+
+	t.PanicOnErr(m.Shutdown(context.Background()))
+	_, err = os.Stat(controlSockAddr)
+	t.Assert().True(os.IsNotExist(err))
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"embly/pkg/core/httpproto"
+	protoutil "embly/pkg/proto-util"
+	"embly/pkg/tracing"
+)
+
+// authResponseHeaders describe an auth function's response rather than the
+// request, so they aren't added to allowed requests
+var authResponseHeaders = map[string]bool{
+	"Content-Type":      true,
+	"Content-Length":    true,
+	"Content-Encoding":  true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+	"Date":              true,
+}
+
+// functionAuthHandler asks an auth function if a request can be handled by h.
+// The auth function receives the request without its body. If it responds
+// with a 2xx status the headers it sent are added to the request, replacing
+// any the client sent with the same name, and the request is passed on. The
+// client's copies of owned headers are removed even if the auth function
+// doesn't set them. Any other response is sent to the client
+func (master *Master) functionAuthHandler(name string, owned []string, timeout time.Duration, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authReq := r
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			authReq = r.WithContext(ctx)
+		}
+		header, err := func() (http.Header, error) {
+			masterG, masterFn, err := master.CheckoutFunction(name)
+			if err != nil {
+				return nil, err
+			}
+			defer master.ReturnFunction(masterG, masterFn)
+			span := master.traceCheckout(tracing.SpanContextFromContext(r.Context()), masterFn)
+			defer span.End()
+			header, err := master.authorize(w, withTraceparent(authReq, span), masterG)
+			span.SetError(err)
+			return header, err
+		}()
+		if err == errFunctionTimeout {
+			http.Error(w, err.Error(), http.StatusGatewayTimeout)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else if header == nil {
+			return
+		}
+		h.ServeHTTP(w, authorizedRequest(r, header, owned))
+	})
+}
+
+// authorizedRequest copies r with the headers from an auth function that
+// allowed it. The client's copies of owned headers are removed first
+func authorizedRequest(r *http.Request, header http.Header, owned []string) *http.Request {
+	r = r.Clone(r.Context())
+	for _, k := range owned {
+		r.Header.Del(k)
+	}
+	for k, values := range header {
+		if !authResponseHeaders[k] {
+			r.Header[k] = values
+		}
+	}
+	return r
+}
+
+// authorize sends a request without its body to the auth function attached to a
+// gateway. It returns the headers to add to the request if the function allowed
+// it, otherwise the function's response is written and header is nil
+func (master *Master) authorize(w http.ResponseWriter, r *http.Request, masterG *Gateway) (header http.Header, err error) {
+	stopWatching := killOnDone(r.Context(), masterG)
+	defer stopWatching()
+
+	reqProto, err := httpproto.DumpRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	reqProto.Params = routeParams(r)
+	reqProto.Eof = true
+	if err = protoutil.WriteMessage(masterG, &reqProto); err != nil {
+		return nil, requestError(r, err)
+	}
+
+	protoRW := httpproto.ReadWriter{ReadWriter: masterG}
+	header = http.Header{}
+	status := http.StatusOK
+	var body []byte
+	for {
+		httpProto, err := protoRW.Next()
+		if err != nil {
+			return nil, requestError(r, err)
+		}
+		addHeaders(header, httpProto.Headers, "")
+		if httpProto.Status != 0 {
+			status = int(httpProto.Status)
+		}
+		body = append(body, httpProto.Body...)
+		if httpProto.Eof {
+			break
+		}
+	}
+	if status >= 200 && status < 300 {
+		return header, nil
+	}
+	for k, values := range header {
+		w.Header()[k] = values
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+	return nil, nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"embly/pkg/core/httpproto"
+	"embly/pkg/tester"
+)
+
+func TestAuthorize(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["auth"] = ""
+
+	f := startFakeFunction(t, m, "auth")
+	defer f.conn.Close()
+
+	go func() {
+		var req httpproto.Http
+		f.next(&req)
+		t.Assert().True(req.Eof)
+		t.Assert().Equal([]string{"session=abc"}, req.Headers["Cookie"].Header)
+		f.send(&httpproto.Http{Headers: map[string]*httpproto.HeaderList{
+			"X-User-Id": {Header: []string{"10"}},
+		}, Eof: true})
+
+		f.next(&req)
+		f.send(&httpproto.Http{Status: http.StatusFound, Headers: map[string]*httpproto.HeaderList{
+			"Location": {Header: []string{"/login"}},
+		}, Body: []byte("sign in"), Eof: true})
+	}()
+
+	r := httptest.NewRequest("GET", "/account", nil)
+	r.Header.Set("Cookie", "session=abc")
+	w := httptest.NewRecorder()
+	header, err := m.authorize(w, r, f.gat)
+	t.PanicOnErr(err)
+	t.Assert().Equal("10", header.Get("X-User-Id"))
+
+	w = httptest.NewRecorder()
+	header, err = m.authorize(w, r, f.gat)
+	t.PanicOnErr(err)
+	t.Assert().Nil(header)
+	t.Assert().Equal(http.StatusFound, w.Code)
+	t.Assert().Equal("/login", w.Header().Get("Location"))
+	t.Assert().Equal("sign in", w.Body.String())
+}
+
+func TestAuthorizedRequest(te *testing.T) {
+	t := tester.New(te)
+	r := httptest.NewRequest("GET", "/account", nil)
+	r.Header.Set("X-User-Id", "1")
+	r.Header.Set("X-Role", "admin")
+	r.Header.Set("X-Other", "kept")
+
+	// the auth function allowed the request without setting X-Role, the
+	// client's copy is removed
+	allowed := authorizedRequest(r, http.Header{
+		"X-User-Id":    {"10"},
+		"Content-Type": {"text/plain"},
+	}, []string{"x-user-id", "X-Role"})
+	t.Assert().Equal("10", allowed.Header.Get("X-User-Id"))
+	t.Assert().Equal("", allowed.Header.Get("X-Role"))
+	t.Assert().Equal("kept", allowed.Header.Get("X-Other"))
+	t.Assert().Equal("", allowed.Header.Get("Content-Type"))
+	t.Assert().Equal("admin", r.Header.Get("X-Role"), "the original request isn't changed")
+}
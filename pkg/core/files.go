@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"embly/pkg/config"
+)
+
+// precompressedEncodings are the encodings of files that are served in place of
+// the original file, in order of preference
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// fileHandler serves the files in a directory. It serves index.html for
+// directories, precompressed siblings of files that the client accepts, ETags
+// and the configured cache control header. Paths without an extension that
+// don't match a file are served the spa fallback if there is one
+type fileHandler struct {
+	root  http.FileSystem
+	files config.Files
+}
+
+func newFileHandler(dir string, files config.Files) http.Handler {
+	return &fileHandler{root: http.Dir(dir), files: files}
+}
+
+// open returns a regular file, or the index.html of a directory
+func (fh *fileHandler) open(name string) (resolved string, f http.File, info os.FileInfo, err error) {
+	if f, err = fh.root.Open(name); err != nil {
+		return
+	}
+	if info, err = f.Stat(); err == nil && info.IsDir() {
+		f.Close()
+		return fh.open(path.Join(name, "index.html"))
+	}
+	if err != nil {
+		f.Close()
+	}
+	return name, f, info, err
+}
+
+func (fh *fileHandler) isDir(name string) bool {
+	f, err := fh.root.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	return err == nil && info.IsDir()
+}
+
+func (fh *fileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	name := path.Clean("/" + r.URL.Path)
+	resolved, f, info, err := fh.open(name)
+	if err == nil && name != "/" && resolved != name && !strings.HasSuffix(r.URL.Path, "/") && fh.isDir(name) {
+		// relative links in a directory's index.html need a trailing slash.
+		// The location is relative because the route's prefix has been
+		// stripped from the path
+		f.Close()
+		w.Header().Set("Location", path.Base(r.URL.Path)+"/")
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+	if err != nil && fh.files.SPAFallback != "" && path.Ext(name) == "" {
+		resolved, f, info, err = fh.open(path.Clean("/" + fh.files.SPAFallback))
+	}
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	header := w.Header()
+	if cacheControl := fh.cacheControl(resolved); cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+	encoding := ""
+	// without a content type the compressed bytes would be sniffed, so only
+	// known types are served precompressed
+	if contentType := mime.TypeByExtension(path.Ext(resolved)); contentType != "" {
+		header.Set("Content-Type", contentType)
+		if cf, cinfo, enc := fh.precompressed(w, r, resolved); cf != nil {
+			defer cf.Close()
+			f, info, encoding = cf, cinfo, enc
+			header.Set("Content-Encoding", encoding)
+		}
+	}
+	header.Set("ETag", etag(info, encoding))
+	http.ServeContent(w, r, resolved, info.ModTime(), f)
+}
+
+// precompressed opens a compressed sibling of a file, like "app.js.br" for
+// "app.js", if the client accepts its encoding
+func (fh *fileHandler) precompressed(w http.ResponseWriter, r *http.Request, name string) (f http.File, info os.FileInfo, encoding string) {
+	accepted := r.Header.Get("Accept-Encoding")
+	vary := false
+	for _, pc := range precompressedEncodings {
+		cf, err := fh.root.Open(name + pc.extension)
+		if err != nil {
+			continue
+		}
+		cinfo, err := cf.Stat()
+		if err != nil || cinfo.IsDir() {
+			cf.Close()
+			continue
+		}
+		if !vary {
+			w.Header().Add("Vary", "Accept-Encoding")
+			vary = true
+		}
+		if !acceptsEncoding(accepted, pc.encoding) {
+			cf.Close()
+			continue
+		}
+		return cf, cinfo, pc.encoding
+	}
+	return nil, nil, ""
+}
+
+// cacheControl is the configured cache control header. Html files are
+// revalidated on every request so that new deploys are picked up
+func (fh *fileHandler) cacheControl(name string) string {
+	if fh.files.CacheControl != "" && path.Ext(name) == ".html" {
+		return "no-cache"
+	}
+	return fh.files.CacheControl
+}
+
+// etag is based on the file's size and modification time, each encoding of a
+// file has its own tag
+func etag(info os.FileInfo, encoding string) string {
+	tag := fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+	return `"` + tag + `"`
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"embly/pkg/config"
+	"embly/pkg/tester"
+)
+
+func TestFileHandler(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "files")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"index.html":       "<html>app</html>",
+		"static/app.js":    "console.log('app')",
+		"static/app.js.br": "brotli",
+		"static/app.js.gz": "gzip",
+		"docs/index.html":  "<html>docs</html>",
+	} {
+		t.PanicOnErr(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		t.PanicOnErr(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	h := newFileHandler(dir, config.Files{SPAFallback: "index.html", CacheControl: "max-age=31536000"})
+
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		for i := 0; i < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		return serve(h, r)
+	}
+
+	w := get("/users/10/settings")
+	t.Assert().Equal(http.StatusOK, w.Code)
+	t.Assert().Equal("<html>app</html>", w.Body.String())
+	t.Assert().Equal("no-cache", w.Header().Get("Cache-Control"))
+
+	t.Assert().Equal(http.StatusNotFound, get("/static/missing.js").Code)
+
+	w = get("/docs")
+	t.Assert().Equal(http.StatusMovedPermanently, w.Code)
+	t.Assert().Equal("docs/", w.Header().Get("Location"))
+	t.Assert().Equal("<html>docs</html>", get("/docs/").Body.String())
+
+	w = get("/static/app.js")
+	t.Assert().Equal("console.log('app')", w.Body.String())
+	t.Assert().Equal("max-age=31536000", w.Header().Get("Cache-Control"))
+	t.Assert().Equal("Accept-Encoding", w.Header().Get("Vary"))
+	etag := w.Header().Get("ETag")
+	t.Assert().NotEmpty(etag)
+	t.Assert().Equal(http.StatusNotModified, get("/static/app.js", "If-None-Match", etag).Code)
+
+	w = get("/static/app.js", "Accept-Encoding", "gzip, br")
+	t.Assert().Equal("brotli", w.Body.String())
+	t.Assert().Equal("br", w.Header().Get("Content-Encoding"))
+	t.Assert().Contains(w.Header().Get("Content-Type"), "javascript")
+	t.Assert().NotEqual(etag, w.Header().Get("ETag"))
+
+	w = get("/static/app.js", "Accept-Encoding", "gzip")
+	t.Assert().Equal("gzip", w.Body.String())
+	t.Assert().Equal("gzip", w.Header().Get("Content-Encoding"))
+
+	w = serve(h, httptest.NewRequest("POST", "/", nil))
+	t.Assert().Equal(http.StatusMethodNotAllowed, w.Code)
+}
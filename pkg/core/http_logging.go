@@ -25,7 +25,7 @@ func logHandler(h http.Handler, ui cli.Ui) http.Handler {
 		ui.Info(fmt.Sprintf("\nStarted %s \"%s\" for %s at %s",
 			r.Method,
 			r.URL.Path,
-			getIP(r),
+			clientIP(r),
 			time.Now().Format("2006-01-02 15:04:05 -0700")))
 		logger := makeLogger(w)
 		h.ServeHTTP(logger, r)
@@ -77,6 +77,7 @@ type commonLoggingResponseWriter interface {
 	SetStatus(int)
 	Status() int
 	Size() int
+	Unwrap() http.ResponseWriter
 }
 
 type loggingResponseWriter interface {
@@ -121,6 +122,12 @@ func (l *responseLogger) Size() int {
 	return l.size
 }
 
+// Unwrap returns the underlying writer so that http.ResponseController can reach
+// it
+func (l *responseLogger) Unwrap() http.ResponseWriter {
+	return l.w
+}
+
 func (l *responseLogger) Flush() {
 	f, ok := l.w.(http.Flusher)
 	if ok {
@@ -170,39 +177,30 @@ var (
 	forRegex = regexp.MustCompile(`(?i)(?:for=)([^(;|,| )]+)`)
 )
 
-// getIP retrieves the IP from the X-Forwarded-For, X-Real-IP and RFC7239
-// Forwarded headers (in that order).
-func getIP(r *http.Request) string {
-	var addr string
-
+// forwardedIPs retrieves the IPs from the X-Forwarded-For, X-Real-IP or RFC7239
+// Forwarded headers (in that order). The client comes first and each proxy
+// that forwarded the request after it. These headers can be set by anyone, so
+// they're only read from trusted proxies.
+func forwardedIPs(r *http.Request) (addrs []string) {
 	if fwd := r.Header.Get(xForwardedFor); fwd != "" {
-		// Only grab the first (client) address. Note that '192.168.0.1,
-		// 10.1.1.1' is a valid key for X-Forwarded-For where addresses after
-		// the first may represent forwarding proxies earlier in the chain.
-		s := strings.Index(fwd, ", ")
-		if s == -1 {
-			s = len(fwd)
+		// '192.168.0.1, 10.1.1.1' is a valid key for X-Forwarded-For where
+		// addresses after the first are forwarding proxies earlier in the
+		// chain.
+		for _, addr := range strings.Split(fwd, ",") {
+			addrs = append(addrs, hostIP(strings.TrimSpace(addr)))
 		}
-		addr = fwd[:s]
 	} else if fwd := r.Header.Get(xRealIP); fwd != "" {
 		// X-Real-IP should only contain one IP address (the client making the
 		// request).
-		addr = fwd
+		addrs = append(addrs, hostIP(fwd))
 	} else if fwd := r.Header.Get(forwarded); fwd != "" {
-		// match should contain at least two elements if the protocol was
-		// specified in the Forwarded header. The first element will always be
-		// the 'for=' capture, which we ignore. In the case of multiple IP
-		// addresses (for=8.8.8.8, 8.8.4.4,172.16.1.20 is valid) we only
-		// extract the first, which should be the client IP.
-		if match := forRegex.FindStringSubmatch(fwd); len(match) > 1 {
+		// Each 'for=' capture is an address in the chain, for=8.8.8.8,
+		// for=8.8.4.4 is valid.
+		for _, match := range forRegex.FindAllStringSubmatch(fwd, -1) {
 			// IPv6 addresses in Forwarded headers are quoted-strings. We strip
 			// these quotes.
-			addr = strings.Trim(match[1], `"`)
+			addrs = append(addrs, hostIP(strings.Trim(match[1], `"`)))
 		}
 	}
-
-	if addr == "" {
-		return r.RemoteAddr
-	}
-	return addr
+	return
 }
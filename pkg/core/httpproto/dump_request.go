@@ -3,6 +3,7 @@ package httpproto
 // stolen from https://golang.org/src/net/http/httputil/dump.go?s=5638:5700#L181
 
 import (
+	"crypto/tls"
 	"net/http"
 	"strings"
 )
@@ -27,20 +28,31 @@ func DumpRequest(req *http.Request) (out Http, err error) {
 	if reqURI == "" {
 		reqURI = req.URL.RequestURI()
 	}
-	out.Method = Http_Method(Http_Method_value[valueOrDefault(req.Method, "GET")])
+	out.MethodName = valueOrDefault(req.Method, "GET")
+	out.Method = Http_OTHER
+	if method, ok := Http_Method_value[out.MethodName]; ok {
+		out.Method = Http_Method(method)
+	}
 	out.Uri = reqURI
 	out.ProtoMajor = int32(req.ProtoMajor)
 	out.ProtoMinor = int32(req.ProtoMinor)
+	out.RemoteAddr = req.RemoteAddr
+	out.Scheme = "http"
+	if req.TLS != nil {
+		out.Scheme = "https"
+		out.Tls = dumpTLS(req.TLS)
+	}
+	if req.URL != nil {
+		out.Query = req.URL.RawQuery
+	}
 
+	out.Host = req.Host
+	if out.Host == "" && req.URL != nil {
+		out.Host = req.URL.Host
+	}
 	absRequestURI := strings.HasPrefix(req.RequestURI, "http://") || strings.HasPrefix(req.RequestURI, "https://")
-	if !absRequestURI {
-		host := req.Host
-		if host == "" && req.URL != nil {
-			host = req.URL.Host
-		}
-		if host != "" {
-			out.Headers["Host"] = &HeaderList{Header: []string{host}}
-		}
+	if !absRequestURI && out.Host != "" {
+		out.Headers["Host"] = &HeaderList{Header: []string{out.Host}}
 	}
 
 	if len(req.TransferEncoding) > 0 {
@@ -63,3 +75,24 @@ func DumpRequest(req *http.Request) (out Http, err error) {
 
 	return
 }
+
+func dumpTLS(state *tls.ConnectionState) *TLS {
+	return &TLS{
+		Version:            tls.VersionName(state.Version),
+		CipherSuite:        tls.CipherSuiteName(state.CipherSuite),
+		ServerName:         state.ServerName,
+		NegotiatedProtocol: state.NegotiatedProtocol,
+	}
+}
+
+// HeaderLists converts http headers to the proto header format
+func HeaderLists(header http.Header) map[string]*HeaderList {
+	if len(header) == 0 {
+		return nil
+	}
+	out := make(map[string]*HeaderList, len(header))
+	for k, vs := range header {
+		out[k] = &HeaderList{Header: vs}
+	}
+	return out
+}
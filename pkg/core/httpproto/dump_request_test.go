@@ -27,3 +27,27 @@ func TestBasicDeserialize(te *testing.T) {
 	t.PanicOnErr(proto.Unmarshal(input, &h))
 	fmt.Println(h)
 }
+
+func TestDumpRequestMetadata(te *testing.T) {
+	t := tester.New(te)
+
+	req := httptest.NewRequest("HEAD", "http://embly.org/health?verbose=1&a=b", nil)
+	out, err := DumpRequest(req)
+	t.PanicOnErr(err)
+	t.Assert().Equal(Http_HEAD, out.Method)
+	t.Assert().Equal("HEAD", out.MethodName)
+	t.Assert().Equal("verbose=1&a=b", out.Query)
+	t.Assert().Equal("embly.org", out.Host)
+	t.Assert().Equal("http", out.Scheme)
+	t.Assert().Equal("192.0.2.1:1234", out.RemoteAddr)
+	t.Assert().Nil(out.Tls)
+
+	req = httptest.NewRequest("PROPFIND", "https://embly.org/", nil)
+	out, err = DumpRequest(req)
+	t.PanicOnErr(err)
+	t.Assert().Equal(Http_OTHER, out.Method)
+	t.Assert().Equal("PROPFIND", out.MethodName)
+	t.Assert().Equal("https", out.Scheme)
+	t.Assert().NotNil(out.Tls)
+	t.Assert().Equal("embly.org", out.Tls.ServerName)
+}
@@ -31,6 +31,9 @@ const (
 	Http_OPTIONS Http_Method = 5
 	Http_TRACE   Http_Method = 6
 	Http_CONNECT Http_Method = 7
+	Http_HEAD    Http_Method = 8
+	// the method isn't one of the above, method_name holds its name
+	Http_OTHER Http_Method = 9
 )
 
 var Http_Method_name = map[int32]string{
@@ -42,6 +45,8 @@ var Http_Method_name = map[int32]string{
 	5: "OPTIONS",
 	6: "TRACE",
 	7: "CONNECT",
+	8: "HEAD",
+	9: "OTHER",
 }
 
 var Http_Method_value = map[string]int32{
@@ -53,6 +58,8 @@ var Http_Method_value = map[string]int32{
 	"OPTIONS": 5,
 	"TRACE":   6,
 	"CONNECT": 7,
+	"HEAD":    8,
+	"OTHER":   9,
 }
 
 func (x Http_Method) String() string {
@@ -63,6 +70,36 @@ func (Http_Method) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_11b04836674e6f94, []int{0, 0}
 }
 
+type Frame_Type int32
+
+const (
+	Frame_TEXT   Frame_Type = 0
+	Frame_BINARY Frame_Type = 1
+	// close frames end the connection, close_code and data hold the close
+	// status code and reason
+	Frame_CLOSE Frame_Type = 2
+)
+
+var Frame_Type_name = map[int32]string{
+	0: "TEXT",
+	1: "BINARY",
+	2: "CLOSE",
+}
+
+var Frame_Type_value = map[string]int32{
+	"TEXT":   0,
+	"BINARY": 1,
+	"CLOSE":  2,
+}
+
+func (x Frame_Type) String() string {
+	return proto.EnumName(Frame_Type_name, int32(x))
+}
+
+func (Frame_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_11b04836674e6f94, []int{3, 0}
+}
+
 type Http struct {
 	ProtoMajor int32                  `protobuf:"varint,1,opt,name=proto_major,json=protoMajor,proto3" json:"proto_major,omitempty"`
 	ProtoMinor int32                  `protobuf:"varint,2,opt,name=proto_minor,json=protoMinor,proto3" json:"proto_minor,omitempty"`
@@ -71,13 +108,28 @@ type Http struct {
 	Headers    map[string]*HeaderList `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Method     Http_Method            `protobuf:"varint,6,opt,name=method,proto3,enum=httpproto.Http_Method" json:"method,omitempty"`
 	Body       []byte                 `protobuf:"bytes,7,opt,name=body,proto3" json:"body,omitempty"`
-	// this is a hack, we just say when the request is over
-	// but http already handles this, so maybe remove it and implement
-	// more complex detection of request completion?
-	Eof                  bool     `protobuf:"varint,8,opt,name=eof,proto3" json:"eof,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	// eof marks the end of a body. Gateways send the request headers, then the
+	// request body in any number of messages, then a message with eof set.
+	// Functions set eof on the last message of their response.
+	Eof bool `protobuf:"varint,8,opt,name=eof,proto3" json:"eof,omitempty"`
+	// the request method exactly as it was sent
+	MethodName string `protobuf:"bytes,9,opt,name=method_name,json=methodName,proto3" json:"method_name,omitempty"`
+	// the ip:port of the client
+	RemoteAddr string `protobuf:"bytes,10,opt,name=remote_addr,json=remoteAddr,proto3" json:"remote_addr,omitempty"`
+	// "http" or "https"
+	Scheme string `protobuf:"bytes,11,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	Host   string `protobuf:"bytes,12,opt,name=host,proto3" json:"host,omitempty"`
+	// the raw query string, without the leading "?"
+	Query string `protobuf:"bytes,13,opt,name=query,proto3" json:"query,omitempty"`
+	// set if the request was made over tls
+	Tls *TLS `protobuf:"bytes,14,opt,name=tls,proto3" json:"tls,omitempty"`
+	// request trailers are sent on the eof message once the body has been read
+	Trailers map[string]*HeaderList `protobuf:"bytes,15,rep,name=trailers,proto3" json:"trailers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// parameters matched by the gateway route, "/users/:id" sets "id"
+	Params               map[string]string `protobuf:"bytes,16,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *Http) Reset()         { *m = Http{} }
@@ -161,6 +213,126 @@ func (m *Http) GetEof() bool {
 	return false
 }
 
+func (m *Http) GetMethodName() string {
+	if m != nil {
+		return m.MethodName
+	}
+	return ""
+}
+
+func (m *Http) GetRemoteAddr() string {
+	if m != nil {
+		return m.RemoteAddr
+	}
+	return ""
+}
+
+func (m *Http) GetScheme() string {
+	if m != nil {
+		return m.Scheme
+	}
+	return ""
+}
+
+func (m *Http) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *Http) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *Http) GetTls() *TLS {
+	if m != nil {
+		return m.Tls
+	}
+	return nil
+}
+
+func (m *Http) GetTrailers() map[string]*HeaderList {
+	if m != nil {
+		return m.Trailers
+	}
+	return nil
+}
+
+func (m *Http) GetParams() map[string]string {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type TLS struct {
+	// the tls version, like "TLS 1.3"
+	Version              string   `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	CipherSuite          string   `protobuf:"bytes,2,opt,name=cipher_suite,json=cipherSuite,proto3" json:"cipher_suite,omitempty"`
+	ServerName           string   `protobuf:"bytes,3,opt,name=server_name,json=serverName,proto3" json:"server_name,omitempty"`
+	NegotiatedProtocol   string   `protobuf:"bytes,4,opt,name=negotiated_protocol,json=negotiatedProtocol,proto3" json:"negotiated_protocol,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TLS) Reset()         { *m = TLS{} }
+func (m *TLS) String() string { return proto.CompactTextString(m) }
+func (*TLS) ProtoMessage()    {}
+func (*TLS) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11b04836674e6f94, []int{1}
+}
+
+func (m *TLS) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TLS.Unmarshal(m, b)
+}
+func (m *TLS) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TLS.Marshal(b, m, deterministic)
+}
+func (m *TLS) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TLS.Merge(m, src)
+}
+func (m *TLS) XXX_Size() int {
+	return xxx_messageInfo_TLS.Size(m)
+}
+func (m *TLS) XXX_DiscardUnknown() {
+	xxx_messageInfo_TLS.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TLS proto.InternalMessageInfo
+
+func (m *TLS) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *TLS) GetCipherSuite() string {
+	if m != nil {
+		return m.CipherSuite
+	}
+	return ""
+}
+
+func (m *TLS) GetServerName() string {
+	if m != nil {
+		return m.ServerName
+	}
+	return ""
+}
+
+func (m *TLS) GetNegotiatedProtocol() string {
+	if m != nil {
+		return m.NegotiatedProtocol
+	}
+	return ""
+}
+
 type HeaderList struct {
 	Header               []string `protobuf:"bytes,1,rep,name=header,proto3" json:"header,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -172,7 +344,7 @@ func (m *HeaderList) Reset()         { *m = HeaderList{} }
 func (m *HeaderList) String() string { return proto.CompactTextString(m) }
 func (*HeaderList) ProtoMessage()    {}
 func (*HeaderList) Descriptor() ([]byte, []int) {
-	return fileDescriptor_11b04836674e6f94, []int{1}
+	return fileDescriptor_11b04836674e6f94, []int{2}
 }
 
 func (m *HeaderList) XXX_Unmarshal(b []byte) error {
@@ -200,37 +372,120 @@ func (m *HeaderList) GetHeader() []string {
 	return nil
 }
 
+// Frame is a websocket message. A function accepts a websocket upgrade request
+// by responding with a 101 status, after that every message sent in either
+// direction is a Frame.
+type Frame struct {
+	Type                 Frame_Type `protobuf:"varint,1,opt,name=type,proto3,enum=httpproto.Frame_Type" json:"type,omitempty"`
+	Data                 []byte     `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	CloseCode            int32      `protobuf:"varint,3,opt,name=close_code,json=closeCode,proto3" json:"close_code,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *Frame) Reset()         { *m = Frame{} }
+func (m *Frame) String() string { return proto.CompactTextString(m) }
+func (*Frame) ProtoMessage()    {}
+func (*Frame) Descriptor() ([]byte, []int) {
+	return fileDescriptor_11b04836674e6f94, []int{3}
+}
+
+func (m *Frame) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Frame.Unmarshal(m, b)
+}
+func (m *Frame) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Frame.Marshal(b, m, deterministic)
+}
+func (m *Frame) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Frame.Merge(m, src)
+}
+func (m *Frame) XXX_Size() int {
+	return xxx_messageInfo_Frame.Size(m)
+}
+func (m *Frame) XXX_DiscardUnknown() {
+	xxx_messageInfo_Frame.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Frame proto.InternalMessageInfo
+
+func (m *Frame) GetType() Frame_Type {
+	if m != nil {
+		return m.Type
+	}
+	return Frame_TEXT
+}
+
+func (m *Frame) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Frame) GetCloseCode() int32 {
+	if m != nil {
+		return m.CloseCode
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("httpproto.Http_Method", Http_Method_name, Http_Method_value)
+	proto.RegisterEnum("httpproto.Frame_Type", Frame_Type_name, Frame_Type_value)
 	proto.RegisterType((*Http)(nil), "httpproto.Http")
 	proto.RegisterMapType((map[string]*HeaderList)(nil), "httpproto.Http.HeadersEntry")
+	proto.RegisterMapType((map[string]string)(nil), "httpproto.Http.ParamsEntry")
+	proto.RegisterMapType((map[string]*HeaderList)(nil), "httpproto.Http.TrailersEntry")
+	proto.RegisterType((*TLS)(nil), "httpproto.TLS")
 	proto.RegisterType((*HeaderList)(nil), "httpproto.HeaderList")
+	proto.RegisterType((*Frame)(nil), "httpproto.Frame")
 }
 
 func init() { proto.RegisterFile("http.proto", fileDescriptor_11b04836674e6f94) }
 
 var fileDescriptor_11b04836674e6f94 = []byte{
-	// 346 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x90, 0xdf, 0x4b, 0xeb, 0x30,
-	0x14, 0xc7, 0x6f, 0xd6, 0x5f, 0xeb, 0xe9, 0xb8, 0x84, 0x03, 0x77, 0x84, 0x8b, 0x60, 0x19, 0x3e,
-	0x14, 0x84, 0x3e, 0x4c, 0x10, 0xf1, 0x6d, 0xd4, 0xe2, 0x84, 0x6d, 0xad, 0x59, 0x7c, 0xd6, 0x8e,
-	0x55, 0x36, 0x75, 0xcb, 0x68, 0x33, 0x61, 0x7f, 0x98, 0xff, 0x9f, 0x24, 0x9d, 0x5a, 0x7c, 0xfb,
-	0xe4, 0x9b, 0x4f, 0x38, 0xdf, 0x1c, 0x80, 0x95, 0x52, 0xbb, 0x78, 0x57, 0x49, 0x25, 0xd1, 0xd7,
-	0x6c, 0x70, 0xf0, 0x61, 0x81, 0x3d, 0x56, 0x6a, 0x87, 0xa7, 0x10, 0x98, 0xe4, 0x71, 0x53, 0xbc,
-	0xc8, 0x8a, 0x91, 0x90, 0x44, 0x0e, 0x07, 0x13, 0x4d, 0x75, 0xd2, 0x12, 0xd6, 0x5b, 0x59, 0xb1,
-	0x4e, 0x5b, 0xd0, 0x09, 0x52, 0xb0, 0xf6, 0xd5, 0x9a, 0x59, 0x21, 0x89, 0x7c, 0xae, 0x11, 0xfb,
-	0xe0, 0xd6, 0xaa, 0x50, 0xfb, 0x9a, 0xd9, 0xc6, 0x3e, 0x9e, 0xf0, 0x12, 0xbc, 0x55, 0x59, 0x2c,
-	0xcb, 0xaa, 0x66, 0x4e, 0x68, 0x45, 0xc1, 0xf0, 0x24, 0xfe, 0x6e, 0x14, 0xeb, 0x36, 0xf1, 0xb8,
-	0xb9, 0x4e, 0xb7, 0xaa, 0x3a, 0xf0, 0x2f, 0x19, 0x63, 0x70, 0x37, 0xa5, 0x5a, 0xc9, 0x25, 0x73,
-	0x43, 0x12, 0xfd, 0x1d, 0xf6, 0x7f, 0x3f, 0x9b, 0x9a, 0x5b, 0x7e, 0xb4, 0x10, 0xc1, 0x5e, 0xc8,
-	0xe5, 0x81, 0x79, 0x21, 0x89, 0x7a, 0xdc, 0xb0, 0x6e, 0x59, 0xca, 0x67, 0xd6, 0x0d, 0x49, 0xd4,
-	0xe5, 0x1a, 0xff, 0xdf, 0x43, 0xaf, 0x3d, 0x4e, 0x1b, 0xaf, 0xe5, 0xc1, 0x6c, 0xc0, 0xe7, 0x1a,
-	0xf1, 0x1c, 0x9c, 0xf7, 0xe2, 0x6d, 0x5f, 0x9a, 0x4f, 0x07, 0xc3, 0x7f, 0xed, 0xb1, 0xe6, 0xe5,
-	0x64, 0x5d, 0x2b, 0xde, 0x38, 0xd7, 0x9d, 0x2b, 0x32, 0x78, 0x02, 0xb7, 0xa9, 0x82, 0x1e, 0x58,
-	0xb7, 0xa9, 0xa0, 0x7f, 0x34, 0xe4, 0x0f, 0x82, 0x12, 0xec, 0x82, 0x9d, 0x67, 0x73, 0x41, 0x3b,
-	0x08, 0xe0, 0xde, 0xa4, 0x93, 0x54, 0xa4, 0xd4, 0x42, 0x1f, 0x9c, 0x7c, 0x24, 0x92, 0x31, 0xb5,
-	0x31, 0x00, 0x2f, 0xcb, 0xc5, 0x5d, 0x36, 0x9b, 0x53, 0x47, 0xe7, 0x82, 0x8f, 0x92, 0x94, 0xba,
-	0x3a, 0x4f, 0xb2, 0xd9, 0x2c, 0x4d, 0x04, 0xf5, 0x06, 0x67, 0x00, 0x3f, 0xa3, 0xf5, 0xa2, 0x9b,
-	0x1d, 0x31, 0x12, 0x5a, 0x91, 0xcf, 0x8f, 0xa7, 0x85, 0x6b, 0x4a, 0x5e, 0x7c, 0x06, 0x00, 0x00,
-	0xff, 0xff, 0x6f, 0x26, 0xa5, 0x9d, 0xfd, 0x01, 0x00, 0x00,
+	// 672 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0x51, 0x6f, 0xd3, 0x30,
+	0x10, 0x26, 0x4d, 0x93, 0x36, 0x97, 0xae, 0x44, 0x66, 0x4c, 0xd6, 0x60, 0x22, 0x54, 0x48, 0x14,
+	0x21, 0x15, 0xa9, 0x93, 0x10, 0xe3, 0xad, 0x74, 0x81, 0x4e, 0xea, 0xda, 0xe2, 0x06, 0x09, 0x9e,
+	0xaa, 0xac, 0x31, 0x34, 0xd0, 0xd6, 0xc5, 0x71, 0x27, 0xf5, 0x4f, 0xf0, 0xc4, 0xff, 0xe4, 0x2f,
+	0xa0, 0xb3, 0xb3, 0x2d, 0x9b, 0x78, 0xe4, 0xed, 0xfc, 0x7d, 0xdf, 0x9d, 0xbf, 0x3b, 0x9f, 0x01,
+	0x16, 0x4a, 0x6d, 0x3a, 0x1b, 0x29, 0x94, 0x20, 0x1e, 0xc6, 0x3a, 0x6c, 0xfd, 0x71, 0xa1, 0x3a,
+	0x50, 0x6a, 0x43, 0x9e, 0x80, 0xaf, 0x91, 0xd9, 0x2a, 0xf9, 0x2e, 0x24, 0xb5, 0x42, 0xab, 0xed,
+	0x30, 0xd0, 0xd0, 0x39, 0x22, 0x25, 0x41, 0xb6, 0x16, 0x92, 0x56, 0xca, 0x02, 0x44, 0x48, 0x00,
+	0xf6, 0x56, 0x66, 0xd4, 0x0e, 0xad, 0xb6, 0xc7, 0x30, 0x24, 0x07, 0xe0, 0xe6, 0x2a, 0x51, 0xdb,
+	0x9c, 0x56, 0xb5, 0xba, 0x38, 0x91, 0xd7, 0x50, 0x5b, 0xf0, 0x24, 0xe5, 0x32, 0xa7, 0x4e, 0x68,
+	0xb7, 0xfd, 0xee, 0xe3, 0xce, 0xb5, 0xa3, 0x0e, 0xba, 0xe9, 0x0c, 0x0c, 0x1d, 0xad, 0x95, 0xdc,
+	0xb1, 0x2b, 0x31, 0xe9, 0x80, 0xbb, 0xe2, 0x6a, 0x21, 0x52, 0xea, 0x86, 0x56, 0xbb, 0xd9, 0x3d,
+	0xb8, 0x9b, 0x76, 0xae, 0x59, 0x56, 0xa8, 0x08, 0x81, 0xea, 0x85, 0x48, 0x77, 0xb4, 0x16, 0x5a,
+	0xed, 0x06, 0xd3, 0x31, 0xba, 0xe4, 0xe2, 0x2b, 0xad, 0x87, 0x56, 0xbb, 0xce, 0x30, 0xc4, 0xc6,
+	0x8c, 0x7e, 0xb6, 0x4e, 0x56, 0x9c, 0x7a, 0xda, 0x3f, 0x18, 0x68, 0x94, 0xac, 0x38, 0x0a, 0x24,
+	0x5f, 0x09, 0xc5, 0x67, 0x49, 0x9a, 0x4a, 0x0a, 0x46, 0x60, 0xa0, 0x5e, 0x9a, 0x4a, 0xdd, 0xe7,
+	0x7c, 0xc1, 0x57, 0x9c, 0xfa, 0x9a, 0x2b, 0x4e, 0x78, 0xff, 0x42, 0xe4, 0x8a, 0x36, 0x34, 0xaa,
+	0x63, 0xb2, 0x0f, 0xce, 0xcf, 0x2d, 0x97, 0x3b, 0xba, 0xa7, 0x41, 0x73, 0x20, 0x21, 0xd8, 0x6a,
+	0x99, 0xd3, 0x66, 0x68, 0xb5, 0xfd, 0x6e, 0xb3, 0xd4, 0x56, 0x3c, 0x9c, 0x32, 0xa4, 0xc8, 0x09,
+	0xd4, 0x95, 0x4c, 0xb2, 0x25, 0x0e, 0xed, 0xbe, 0x1e, 0xda, 0xd1, 0xdd, 0xee, 0xe3, 0x82, 0x37,
+	0x53, 0xbb, 0x96, 0x93, 0x63, 0x70, 0x37, 0x89, 0x4c, 0x56, 0x39, 0x0d, 0x74, 0xe2, 0xa3, 0xbb,
+	0x89, 0x13, 0xcd, 0x9a, 0xb4, 0x42, 0x7a, 0xf8, 0x11, 0x1a, 0xe5, 0x47, 0xc0, 0xb9, 0xfd, 0xe0,
+	0x3b, 0xbd, 0x17, 0x1e, 0xc3, 0x90, 0xbc, 0x04, 0xe7, 0x32, 0x59, 0x6e, 0xb9, 0x5e, 0x05, 0xbf,
+	0xfb, 0xb0, 0x5c, 0x55, 0x67, 0x0e, 0xb3, 0x5c, 0x31, 0xa3, 0x79, 0x5b, 0x79, 0x63, 0x1d, 0x32,
+	0xd8, 0xbb, 0x65, 0xf1, 0x7f, 0xd4, 0x3c, 0x01, 0xbf, 0xe4, 0xfe, 0x1f, 0x15, 0xf7, 0xcb, 0x15,
+	0xbd, 0x52, 0x6a, 0x6b, 0x0b, 0xae, 0xd9, 0x17, 0x52, 0x03, 0xfb, 0x43, 0x14, 0x07, 0xf7, 0x30,
+	0x98, 0x7c, 0x8a, 0x03, 0x8b, 0xd4, 0xa1, 0x3a, 0x19, 0x4f, 0xe3, 0xa0, 0x42, 0x00, 0xdc, 0xd3,
+	0x68, 0x18, 0xc5, 0x51, 0x60, 0x13, 0x0f, 0x9c, 0x49, 0x2f, 0xee, 0x0f, 0x82, 0x2a, 0xf1, 0xa1,
+	0x36, 0x9e, 0xc4, 0x67, 0xe3, 0xd1, 0x34, 0x70, 0x10, 0x8f, 0x59, 0xaf, 0x1f, 0x05, 0x2e, 0xe2,
+	0xfd, 0xf1, 0x68, 0x14, 0xf5, 0xe3, 0xa0, 0x86, 0x55, 0x06, 0x51, 0xef, 0x34, 0xa8, 0xa3, 0x62,
+	0x1c, 0x0f, 0x22, 0x16, 0x78, 0xad, 0xdf, 0x16, 0xd8, 0xf1, 0x70, 0x4a, 0x28, 0xd4, 0x2e, 0xb9,
+	0xcc, 0x33, 0xb1, 0x2e, 0xec, 0x5e, 0x1d, 0xc9, 0x53, 0x68, 0xcc, 0xb3, 0xcd, 0x82, 0xcb, 0x59,
+	0xbe, 0xcd, 0xd4, 0x95, 0x73, 0xdf, 0x60, 0x53, 0x84, 0x70, 0x25, 0x73, 0x2e, 0x2f, 0xb9, 0x34,
+	0x3b, 0x6b, 0xfe, 0x1c, 0x18, 0x48, 0xef, 0xec, 0x2b, 0x78, 0xb0, 0xe6, 0xdf, 0x84, 0xca, 0x12,
+	0xc5, 0xd3, 0x99, 0x9e, 0xe0, 0x5c, 0x2c, 0xf5, 0x3f, 0xf4, 0x18, 0xb9, 0xa1, 0x26, 0x05, 0xd3,
+	0x7a, 0x06, 0x70, 0x33, 0x61, 0xdc, 0x68, 0xf3, 0xe9, 0xa8, 0x15, 0xda, 0xb8, 0xd1, 0xe6, 0xd4,
+	0xfa, 0x65, 0x81, 0xf3, 0x5e, 0xe2, 0x05, 0x2f, 0xa0, 0xaa, 0x76, 0x1b, 0xae, 0xbd, 0x37, 0x6f,
+	0x3d, 0x94, 0xe6, 0x3b, 0xf1, 0x6e, 0xc3, 0x99, 0x96, 0xe0, 0x37, 0x48, 0x13, 0x95, 0xe8, 0x3e,
+	0x1a, 0x4c, 0xc7, 0xe4, 0x08, 0x60, 0xbe, 0x14, 0x39, 0x9f, 0xcd, 0x45, 0x6a, 0xfc, 0x3b, 0xcc,
+	0xd3, 0x48, 0x5f, 0xa4, 0xbc, 0xf5, 0x1c, 0xaa, 0x58, 0x00, 0x27, 0x18, 0x47, 0x9f, 0xf1, 0x69,
+	0x00, 0xdc, 0x77, 0x67, 0xa3, 0x1e, 0xfb, 0x12, 0x58, 0x38, 0xcd, 0xfe, 0x70, 0x3c, 0x8d, 0x82,
+	0xca, 0x85, 0xab, 0xef, 0x3c, 0xfe, 0x3b, 0x00, 0x3b, 0x3a, 0xf1, 0x7c, 0xdf, 0x04, 0x00, 0x00,
 }
@@ -2,6 +2,7 @@ package httpproto
 
 import (
 	"io"
+	"net/http"
 
 	protoutil "embly/pkg/proto-util"
 )
@@ -19,6 +20,15 @@ func (rw *ReadWriter) Write(b []byte) (ln int, err error) {
 	return len(b), nil
 }
 
+// WriteEOF sends the message that marks the end of a body along with any
+// trailers
+func (rw *ReadWriter) WriteEOF(trailers http.Header) (err error) {
+	return protoutil.WriteMessage(rw.ReadWriter, &Http{
+		Eof:      true,
+		Trailers: HeaderLists(trailers),
+	})
+}
+
 func (rw *ReadWriter) Next() (httpProto Http, err error) {
 	err = protoutil.NextMessage(rw.ReadWriter, &httpProto)
 	return
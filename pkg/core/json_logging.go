@@ -0,0 +1,180 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The log formats accepted by StartConfig. Text logs are written for people
+// reading a terminal, json logs are one object per line for log aggregators
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// maxOutputLine is the longest line of function output that is logged as one
+// entry, longer lines are split
+const maxOutputLine = 64 * 1024
+
+// jsonLogger writes one json object per line
+type jsonLogger struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func logTime() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func (l *jsonLogger) log(entry interface{}) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.w.Write(append(b, '\n'))
+}
+
+// accessLogEntry is logged for every request to an http gateway
+type accessLogEntry struct {
+	Time     string  `json:"time"`
+	Type     string  `json:"type"`
+	Method   string  `json:"method"`
+	Host     string  `json:"host"`
+	Path     string  `json:"path"`
+	Status   int     `json:"status"`
+	Size     int     `json:"size"`
+	Duration float64 `json:"duration_ms"`
+	Function string  `json:"function,omitempty"`
+	Files    string  `json:"files,omitempty"`
+	ClientIP string  `json:"client_ip"`
+}
+
+// handler logs requests to a function or files route
+func (l *jsonLogger) handler(h http.Handler, function, files string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger := makeLogger(w)
+		h.ServeHTTP(logger, r)
+		l.log(accessLogEntry{
+			Time:     logTime(),
+			Type:     "access",
+			Method:   r.Method,
+			Host:     r.Host,
+			Path:     r.URL.Path,
+			Status:   logger.Status(),
+			Size:     logger.Size(),
+			Duration: float64(time.Since(start)) / float64(time.Millisecond),
+			Function: function,
+			Files:    files,
+			ClientIP: clientIP(r),
+		})
+	})
+}
+
+// outputLogEntry is a line written by a function to stdout or stderr
+type outputLogEntry struct {
+	Time     string `json:"time"`
+	Type     string `json:"type"`
+	Function string `json:"function"`
+	Addr     uint64 `json:"addr,string"`
+	Stream   string `json:"stream"`
+	Message  string `json:"message"`
+}
+
+// outputWriter splits a function's output into lines and passes each one to
+// emit
+type outputWriter struct {
+	emit  func(outputLogEntry)
+	entry outputLogEntry
+	buf   []byte
+}
+
+func newOutputWriter(function string, addr uint64, stream string, emit func(outputLogEntry)) *outputWriter {
+	return &outputWriter{emit: emit, entry: outputLogEntry{
+		Type:     "output",
+		Function: function,
+		Addr:     addr,
+		Stream:   stream,
+	}}
+}
+
+func (l *jsonLogger) outputWriter(function string, addr uint64, stream string) *outputWriter {
+	return newOutputWriter(function, addr, stream, func(entry outputLogEntry) { l.log(entry) })
+}
+
+func (ow *outputWriter) logLine(line []byte) {
+	entry := ow.entry
+	entry.Time = logTime()
+	entry.Message = strings.TrimSuffix(string(line), "\r")
+	ow.emit(entry)
+}
+
+func (ow *outputWriter) Write(p []byte) (n int, err error) {
+	ow.buf = append(ow.buf, p...)
+	for {
+		i := bytes.IndexByte(ow.buf, '\n')
+		if i == -1 {
+			break
+		}
+		ow.logLine(ow.buf[:i])
+		ow.buf = ow.buf[i+1:]
+	}
+	for len(ow.buf) > maxOutputLine {
+		ow.logLine(ow.buf[:maxOutputLine])
+		ow.buf = ow.buf[maxOutputLine:]
+	}
+	return len(p), nil
+}
+
+// Flush logs output that didn't end with a newline
+func (ow *outputWriter) Flush() {
+	if len(ow.buf) > 0 {
+		ow.logLine(ow.buf)
+		ow.buf = nil
+	}
+}
+
+// messageLogEntry is a message that would otherwise be written to the
+// terminal
+type messageLogEntry struct {
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// jsonUi is a cli.Ui that logs messages as json
+type jsonUi struct {
+	logger *jsonLogger
+}
+
+func (u *jsonUi) message(level, message string) {
+	u.logger.log(messageLogEntry{
+		Time:    logTime(),
+		Type:    "message",
+		Level:   level,
+		Message: strings.TrimSpace(message),
+	})
+}
+
+func (u *jsonUi) Ask(string) (string, error) {
+	return "", errors.New("can't ask for input while logging json")
+}
+
+func (u *jsonUi) AskSecret(string) (string, error) {
+	return "", errors.New("can't ask for input while logging json")
+}
+
+func (u *jsonUi) Output(message string) { u.message("info", message) }
+func (u *jsonUi) Info(message string)   { u.message("info", message) }
+func (u *jsonUi) Error(message string)  { u.message("error", message) }
+func (u *jsonUi) Warn(message string)   { u.message("warn", message) }
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"embly/pkg/tester"
+)
+
+func logLines(t tester.Tester, buf *bytes.Buffer) (lines []map[string]interface{}) {
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := map[string]interface{}{}
+		t.PanicOnErr(json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return
+}
+
+func TestJSONAccessLog(te *testing.T) {
+	t := tester.New(te)
+	var buf bytes.Buffer
+	logger := &jsonLogger{w: &buf}
+	h := logger.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}), "function.foo", "")
+
+	r := httptest.NewRequest("POST", "http://example.com/users?id=1", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	entry := logLines(t, &buf)[0]
+	t.Assert().Equal("access", entry["type"])
+	t.Assert().Equal("POST", entry["method"])
+	t.Assert().Equal("example.com", entry["host"])
+	t.Assert().Equal("/users", entry["path"])
+	t.Assert().Equal(float64(http.StatusCreated), entry["status"])
+	t.Assert().Equal(float64(len("created")), entry["size"])
+	t.Assert().Equal("function.foo", entry["function"])
+	t.Assert().Equal("192.0.2.1", entry["client_ip"])
+	t.Assert().NotContains(entry, "files")
+	t.Assert().Contains(entry, "duration_ms")
+}
+
+func TestJSONFunctionOutput(te *testing.T) {
+	t := tester.New(te)
+	var buf bytes.Buffer
+	logger := &jsonLogger{w: &buf}
+	ow := logger.outputWriter("function.foo", 18446744073709551615, "stderr")
+	ow.Write([]byte("first line\r\nsecond "))
+	ow.Write([]byte("line\nno newline"))
+	ow.Flush()
+
+	lines := logLines(t, &buf)
+	t.Assert().Len(lines, 3)
+	for i, message := range []string{"first line", "second line", "no newline"} {
+		t.Assert().Equal(message, lines[i]["message"])
+		t.Assert().Equal("output", lines[i]["type"])
+		t.Assert().Equal("function.foo", lines[i]["function"])
+		t.Assert().Equal("18446744073709551615", lines[i]["addr"])
+		t.Assert().Equal("stderr", lines[i]["stream"])
+	}
+
+	buf.Reset()
+	ui := &jsonUi{logger: logger}
+	ui.Error("\nsomething broke\n")
+	entry := logLines(t, &buf)[0]
+	t.Assert().Equal("error", entry["level"])
+	t.Assert().Equal("something broke", entry["message"])
+}
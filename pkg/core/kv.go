@@ -2,92 +2,226 @@ package core
 
 import (
 	"net"
+	"path/filepath"
 	"strings"
 
+	"embly/pkg/build"
+	"embly/pkg/config"
 	comms_proto "embly/pkg/core/proto"
 	"embly/pkg/kv"
+	"embly/pkg/tracing"
 
 	"github.com/pkg/errors"
 )
 
 // KV is the send/recv context for the KV store
 type KV struct {
-	master   *Master
-	id       uint64
-	conn     net.Conn
-	isGetter bool
+	master *Master
+	id     uint64
+	owner  uint64
+	conn   net.Conn
+	store  kv.Store
 
-	// the action path "connect" "request"
+	namespace string
+	// the kv command "get", "set", "delete", "exists", "scan" or "cas"
 	path string
 }
 
-var store = kv.NewMemoryStore()
+var kvCommands = map[string]bool{
+	"get":    true,
+	"set":    true,
+	"delete": true,
+	"exists": true,
+	"scan":   true,
+	"cas":    true,
+}
+
+func boolByte(b bool) []byte {
+	if b {
+		return []byte{1}
+	}
+	return []byte{0}
+}
 
 func (k *KV) processRequest(msg comms_proto.Message) (err error) {
-	if k.isGetter {
-		value, err := store.Get(msg.Data)
+	store := k.store
+	var data []byte
+	switch k.path {
+	case "get":
+		if data, err = store.Get(msg.Data); err != nil {
+			return err
+		}
+	case "set":
+		key, value, ttl, err := kv.ExtractKeyValueAndTTL(msg.Data)
 		if err != nil {
 			return err
 		}
-		if err := WriteMessage(k.conn, comms_proto.Message{
-			Data: value,
-			From: msg.To,
-			To:   msg.From,
-		}); err != nil {
+		if err := store.SetWithTTL(key, value, ttl); err != nil {
 			return err
 		}
-	} else {
-		key, value, err := kv.ExtractKeyAndValue(msg.Data)
+	case "delete":
+		if err := store.Delete(msg.Data); err != nil {
+			return err
+		}
+	case "exists":
+		exists, err := store.Exists(msg.Data)
+		if err != nil {
+			return err
+		}
+		data = boolByte(exists)
+	case "scan":
+		prefix, limit, err := kv.ExtractScanRequest(msg.Data)
 		if err != nil {
 			return err
 		}
-		if err := store.Set(key, value); err != nil {
+		pairs, err := store.Scan(prefix, limit)
+		if err != nil {
+			return err
+		}
+		if data, err = kv.WriteKeyValues(pairs); err != nil {
+			return err
+		}
+	case "cas":
+		key, old, value, err := kv.ExtractCompareAndSwap(msg.Data)
+		if err != nil {
 			return err
 		}
-		if err := WriteMessage(k.conn, comms_proto.Message{
-			From: msg.To,
-			To:   msg.From,
-		}); err != nil {
+		swapped, err := store.CompareAndSwap(key, old, value)
+		if err != nil {
 			return err
 		}
+		data = boolByte(swapped)
 	}
-	return nil
+	return WriteMessage(k.conn, comms_proto.Message{
+		Data: data,
+		From: msg.To,
+		To:   msg.From,
+	})
 }
 
 func (k *KV) sendMsg(msg comms_proto.Message) {
-	if err := k.processRequest(msg); err != nil {
+	parent, _ := tracing.ParseTraceparent(msg.Traceparent)
+	span := k.master.startChildSpan("kv "+k.path, parent, tracing.SpanKindClient)
+	span.SetAttribute("kv.namespace", k.namespace)
+	span.SetAttribute("kv.command", k.path)
+	err := k.processRequest(msg)
+	span.SetError(err)
+	span.End()
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	k.master.metrics.kvOperations.Inc(k.namespace, k.path, result)
+	if err != nil {
 		WriteMessage(k.conn, comms_proto.Message{
 			Data:  []byte(err.Error()),
 			From:  msg.To,
 			To:    msg.From,
-			Error: 28,
+			Error: comms_proto.ErrorCode_KV_ERROR,
 		})
 	}
 }
 
-func (master *Master) spawnKV(msg comms_proto.Message, conn net.Conn) (err error) {
-	parts := strings.Split(msg.Spawn, "/")
-	if len(parts) < 3 {
-		return errors.New("missing command on kv request, should be embly/kv/set or embly/kv/get")
+// parseKVSpawn splits a kv spawn path into its namespace and command. Paths
+// are embly/kv/<namespace>/<command>, embly/kv/<command> uses the default
+// namespace
+func parseKVSpawn(spawn string) (namespace string, command string, err error) {
+	parts := strings.Split(spawn, "/")
+	switch len(parts) {
+	case 3:
+		namespace, command = config.DefaultKVNamespace, parts[2]
+	case 4:
+		namespace, command = parts[2], parts[3]
+	default:
+		err = errors.New("invalid kv request, should be embly/kv/<namespace>/<command>")
+		return
+	}
+	if !kvCommands[command] {
+		err = errors.Errorf(`"%s" is not a known kv command`, command)
+	}
+	return
+}
+
+// kvStore returns the store for namespace. A master that wasn't given any
+// stores keeps the default namespace in memory
+func (master *Master) kvStore(namespace string) (store kv.Store, ok bool) {
+	master.mutex.Lock()
+	defer master.mutex.Unlock()
+	if master.kvStores == nil {
+		master.kvStores = map[string]kv.Store{config.DefaultKVNamespace: kv.NewMemoryStore()}
 	}
+	store, ok = master.kvStores[namespace]
+	return
+}
 
-	path := parts[2]
+func (master *Master) spawnKV(msg comms_proto.Message, conn net.Conn) (err error) {
+	namespace, path, err := parseKVSpawn(msg.Spawn)
+	if err != nil {
+		return err
+	}
+	store, ok := master.kvStore(namespace)
+	if !ok {
+		return errors.Errorf(`kv namespace "%s" doesn't exist`, namespace)
+	}
 
 	k := &KV{
 		master: master,
 		id:     msg.SpawnAddress,
+		owner:  msg.From,
 		conn:   conn,
-		path:   path,
-	}
+		store:  store,
 
-	if path == "get" {
-		k.isGetter = true
-	} else if path == "set" {
-		k.isGetter = false
-	} else {
-		return errors.New("path is not a known command")
+		namespace: namespace,
+		path:      path,
 	}
 
 	master.addFuncOrGateway(msg.SpawnAddress, k)
 	return nil
 }
+
+// openKVStore opens a namespace's store using the embly.hcl kv_store block.
+// Disk stores for the default namespace are kept in kv.db and other namespaces
+// in kv-<namespace>.db
+func openKVStore(builder *build.Builder, ns config.KVNamespace) (store kv.Store, err error) {
+	cfg := builder.Config.KVStore
+	if cfg == nil || cfg.Type == "" || cfg.Type == "memory" {
+		store = kv.NewMemoryStore()
+	} else {
+		dir := filepath.Join(builder.EmblyBuildDir(), "kv")
+		if cfg.Path != "" {
+			dir = filepath.Join(builder.ProjectRoot, cfg.Path)
+		}
+		name := "kv.db"
+		if ns.Name != config.DefaultKVNamespace {
+			name = "kv-" + ns.Name + ".db"
+		}
+		if store, err = kv.NewBoltStore(filepath.Join(dir, name)); err != nil {
+			return
+		}
+	}
+	if ns.Quota > 0 {
+		store = kv.NewQuotaStore(store, ns.Quota)
+	}
+	return
+}
+
+// openKVStores opens a store for every kv namespace, closing any that were
+// opened if one fails
+func openKVStores(builder *build.Builder) (stores map[string]kv.Store, err error) {
+	stores = make(map[string]kv.Store)
+	for _, ns := range builder.Config.KVNamespaces() {
+		store, err := openKVStore(builder, ns)
+		if err != nil {
+			closeKVStores(stores)
+			return nil, err
+		}
+		stores[ns.Name] = store
+	}
+	return
+}
+
+func closeKVStores(stores map[string]kv.Store) {
+	for _, store := range stores {
+		store.Close()
+	}
+}
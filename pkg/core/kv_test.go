@@ -1 +1,124 @@
 package core
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"embly/pkg/config"
+	comms_proto "embly/pkg/core/proto"
+	"embly/pkg/kv"
+	"embly/pkg/tester"
+)
+
+// kvRequest spawns a kv command and sends it data, returning the reply
+func kvRequest(t tester.Tester, conn net.Conn, from uint64, spawn string, data []byte) comms_proto.Message {
+	addr := rand.Uint64()
+	t.PanicOnErr(WriteMessage(conn, comms_proto.Message{
+		Spawn:        spawn,
+		SpawnAddress: addr,
+		From:         from,
+	}))
+	t.PanicOnErr(WriteMessage(conn, comms_proto.Message{
+		To:   addr,
+		From: from,
+		Data: data,
+	}))
+	reply, err := NextMessage(conn)
+	t.PanicOnErr(err)
+	t.Assert().Equal(addr, reply.From)
+	return reply
+}
+
+func TestKVCommands(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	fn, err := m.NewFunction("foo", 1, nil, nil)
+	t.PanicOnErr(err)
+	conn := connectFakeFunction(t, fn)
+	defer conn.Close()
+
+	b, _ := kv.WriteKeyAndValue([]byte("user/1"), []byte("one"))
+	t.Assert().Equal(comms_proto.ErrorCode_NONE, kvRequest(t, conn, fn.addr, "embly/kv/set", b).Error)
+	t.Assert().Equal([]byte("one"), kvRequest(t, conn, fn.addr, "embly/kv/get", []byte("user/1")).Data)
+	t.Assert().Equal([]byte{1}, kvRequest(t, conn, fn.addr, "embly/kv/exists", []byte("user/1")).Data)
+
+	b, _ = kv.WriteScanRequest([]byte("user/"), 0)
+	pairs, err := kv.ExtractKeyValues(kvRequest(t, conn, fn.addr, "embly/kv/scan", b).Data)
+	t.PanicOnErr(err)
+	t.Assert().Equal([]kv.KeyValue{{Key: []byte("user/1"), Value: []byte("one")}}, pairs)
+
+	b, _ = kv.WriteCompareAndSwap([]byte("user/1"), []byte("two"), []byte("three"))
+	t.Assert().Equal([]byte{0}, kvRequest(t, conn, fn.addr, "embly/kv/cas", b).Data)
+	b, _ = kv.WriteCompareAndSwap([]byte("user/1"), []byte("one"), []byte("two"))
+	t.Assert().Equal([]byte{1}, kvRequest(t, conn, fn.addr, "embly/kv/cas", b).Data)
+
+	kvRequest(t, conn, fn.addr, "embly/kv/delete", []byte("user/1"))
+	t.Assert().Equal([]byte{0}, kvRequest(t, conn, fn.addr, "embly/kv/exists", []byte("user/1")).Data)
+	t.Assert().Equal(comms_proto.ErrorCode_KV_ERROR, kvRequest(t, conn, fn.addr, "embly/kv/get", []byte("user/1")).Error)
+
+	b, _ = kv.WriteKeyValueAndTTL([]byte("token"), []byte("abc"), time.Millisecond)
+	kvRequest(t, conn, fn.addr, "embly/kv/set", b)
+	time.Sleep(5 * time.Millisecond)
+	t.Assert().Equal([]byte{0}, kvRequest(t, conn, fn.addr, "embly/kv/exists", []byte("token")).Data)
+
+	t.Assert().Equal(float64(2), m.metrics.kvOperations.Value("default", "set", "ok"))
+	t.Assert().Equal(float64(1), m.metrics.kvOperations.Value("default", "get", "error"))
+}
+
+func TestKVNamespaces(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.kvStores = map[string]kv.Store{
+		config.DefaultKVNamespace: kv.NewMemoryStore(),
+		"sessions":                kv.NewQuotaStore(kv.NewMemoryStore(), 10),
+	}
+	defer closeKVStores(m.kvStores)
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	fn, err := m.NewFunction("foo", 1, nil, nil)
+	t.PanicOnErr(err)
+	conn := connectFakeFunction(t, fn)
+	defer conn.Close()
+
+	b, _ := kv.WriteKeyAndValue([]byte("key"), []byte("default"))
+	kvRequest(t, conn, fn.addr, "embly/kv/set", b)
+	b, _ = kv.WriteKeyAndValue([]byte("key"), []byte("session"))
+	kvRequest(t, conn, fn.addr, "embly/kv/sessions/set", b)
+
+	t.Assert().Equal([]byte("default"), kvRequest(t, conn, fn.addr, "embly/kv/default/get", []byte("key")).Data)
+	t.Assert().Equal([]byte("session"), kvRequest(t, conn, fn.addr, "embly/kv/sessions/get", []byte("key")).Data)
+
+	b, _ = kv.WriteKeyAndValue([]byte("other"), []byte("value"))
+	reply := kvRequest(t, conn, fn.addr, "embly/kv/sessions/set", b)
+	t.Assert().Equal(comms_proto.ErrorCode_KV_ERROR, reply.Error)
+	t.Assert().Contains(string(reply.Data), "quota")
+
+	t.PanicOnErr(WriteMessage(conn, comms_proto.Message{
+		Spawn:        "embly/kv/nope/get",
+		SpawnAddress: rand.Uint64(),
+		From:         fn.addr,
+	}))
+	reply, err = NextMessage(conn)
+	t.PanicOnErr(err)
+	t.Assert().Equal(comms_proto.ErrorCode_INVALID_SPAWN, reply.Error)
+}
+
+func TestDefaultKVStore(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	t.Assert().Nil(m.kvStores, "stores are only created when they're used")
+	store, ok := m.kvStore(config.DefaultKVNamespace)
+	t.Assert().True(ok)
+	defer store.Close()
+	_, ok = m.kvStore("sessions")
+	t.Assert().False(ok)
+}
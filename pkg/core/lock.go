@@ -0,0 +1,62 @@
+package core
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// lockFileAddr is the location of the lock file that is held by the master
+// listening on sockAddr
+func lockFileAddr(sockAddr string) string {
+	return strings.TrimSuffix(sockAddr, ".sock") + ".lock"
+}
+
+// makeSockDir creates the socket directory, or checks that an existing one is
+// a directory that only we can access and not a link somewhere else
+func makeSockDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "error creating socket directory")
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return errors.Wrap(err, "error checking socket directory")
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 || !ok || int(stat.Uid) != os.Getuid() {
+		return errors.Errorf("socket directory %s must be a directory that only you can access", dir)
+	}
+	return nil
+}
+
+// lockSocket takes the lock file for sockAddr and writes our pid to it. The lock
+// is released when the file is closed or the process exits, so a lock file left
+// behind by a process that crashed doesn't stop a new master from starting. A
+// link at the lock file's location isn't followed
+func lockSocket(sockAddr string) (lock *os.File, err error) {
+	lock, err = os.OpenFile(lockFileAddr(sockAddr), os.O_RDWR|os.O_CREATE|syscall.O_NOFOLLOW, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening lock file")
+	}
+	if err = syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		pid, _ := ioutil.ReadAll(lock)
+		lock.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errors.Errorf("embly is already running on %s with pid %s, pass --socket to run another instance of this project",
+				sockAddr, strings.TrimSpace(string(pid)))
+		}
+		return nil, errors.Wrap(err, "error locking lock file")
+	}
+	if err = lock.Truncate(0); err == nil {
+		_, err = lock.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err != nil {
+		lock.Close()
+		return nil, errors.Wrap(err, "error writing lock file")
+	}
+	return lock, nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"embly/pkg/tester"
+)
+
+func TestSocketLock(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+	sockAddr := filepath.Join(dir, "embly.sock")
+
+	first := NewMaster()
+	first.sockAddr = sockAddr
+	_, err = first.listen()
+	t.PanicOnErr(err)
+
+	second := NewMaster()
+	second.sockAddr = sockAddr
+	_, err = second.listen()
+	t.ErrorContains(err, fmt.Sprintf("already running on %s with pid %d", sockAddr, os.Getpid()))
+	// a master that never listened leaves the running master's socket alone
+	t.PanicOnErr(second.Shutdown(context.Background()))
+	_, err = os.Stat(sockAddr)
+	t.PanicOnErr(err)
+
+	t.PanicOnErr(first.Shutdown(context.Background()))
+	_, err = os.Stat(sockAddr)
+	t.Assert().True(os.IsNotExist(err))
+
+	// the lock is released on shutdown
+	third := NewMaster()
+	third.sockAddr = sockAddr
+	l, err := third.listen()
+	t.PanicOnErr(err)
+	t.Assert().NotNil(l)
+	t.PanicOnErr(third.Shutdown(context.Background()))
+
+	// links aren't followed when the lock file is opened
+	target := filepath.Join(dir, "target")
+	linked := filepath.Join(dir, "linked.sock")
+	t.PanicOnErr(os.Symlink(target, lockFileAddr(linked)))
+	_, err = lockSocket(linked)
+	t.Assert().Error(err)
+	_, err = os.Stat(target)
+	t.Assert().True(os.IsNotExist(err))
+}
+
+func TestMakeSockDir(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+
+	sockDir := filepath.Join(dir, "embly")
+	t.PanicOnErr(makeSockDir(sockDir))
+	info, err := os.Stat(sockDir)
+	t.PanicOnErr(err)
+	t.Assert().Equal(os.FileMode(0700), info.Mode().Perm())
+	t.PanicOnErr(makeSockDir(sockDir))
+
+	// other users could replace sockets in a shared directory
+	t.PanicOnErr(os.Chmod(sockDir, 0777))
+	t.ErrorContains(makeSockDir(sockDir), "only you can access")
+
+	// or point a link at a directory of their own
+	other := filepath.Join(dir, "other")
+	t.PanicOnErr(os.Mkdir(other, 0700))
+	link := filepath.Join(dir, "link")
+	t.PanicOnErr(os.Symlink(other, link))
+	t.ErrorContains(makeSockDir(link), "only you can access")
+}
+
+func TestProjectSockAddr(te *testing.T) {
+	t := tester.New(te)
+	a := ProjectSockAddr("/home/embly/app")
+	t.Assert().Equal(a, ProjectSockAddr("/home/embly/app/"))
+	t.Assert().NotEqual(a, ProjectSockAddr("/home/embly/other"))
+	t.Assert().Equal(SockDir, filepath.Dir(a))
+	t.Assert().Regexp(`^embly-[0-9a-f]{12}\.sock$`, filepath.Base(a))
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// maxRecentOutput is the number of lines of function output that are kept for
+// clients that ask for recent output
+const maxRecentOutput = 1000
+
+// followerBuffer is the number of lines that can be waiting for a follower,
+// followers that fall further behind miss lines
+const followerBuffer = 256
+
+// outputLog keeps the most recent lines of function output and passes new lines
+// to followers
+type outputLog struct {
+	mutex     sync.Mutex
+	recent    []outputLogEntry
+	followers map[chan outputLogEntry]struct{}
+	// closed is closed on shutdown so that followers stop
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newOutputLog() *outputLog {
+	return &outputLog{
+		followers: make(map[chan outputLogEntry]struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+func (ol *outputLog) close() {
+	ol.closeOnce.Do(func() { close(ol.closed) })
+}
+
+func (ol *outputLog) publish(entry outputLogEntry) {
+	ol.mutex.Lock()
+	defer ol.mutex.Unlock()
+	ol.recent = append(ol.recent, entry)
+	if len(ol.recent) > maxRecentOutput {
+		ol.recent = append([]outputLogEntry{}, ol.recent[len(ol.recent)-maxRecentOutput:]...)
+	}
+	for ch := range ol.followers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// follow returns the recent lines of output and a channel of new lines. stop
+// must be called once the caller is done reading
+func (ol *outputLog) follow() (recent []outputLogEntry, lines chan outputLogEntry, stop func()) {
+	ol.mutex.Lock()
+	defer ol.mutex.Unlock()
+	recent = append([]outputLogEntry{}, ol.recent...)
+	lines = make(chan outputLogEntry, followerBuffer)
+	ol.followers[lines] = struct{}{}
+	return recent, lines, func() {
+		ol.mutex.Lock()
+		defer ol.mutex.Unlock()
+		delete(ol.followers, lines)
+	}
+}
+
+// publishOutput logs a line of function output and passes it to anyone
+// following the logs
+func (m *Master) publishOutput(entry outputLogEntry) {
+	if m.jsonLog != nil {
+		m.jsonLog.log(entry)
+	}
+	m.output.publish(entry)
+}
+
+// matchesFunction reports whether a line was written by function, which can be
+// given with or without the "function." prefix. An empty function matches
+// every line
+func matchesFunction(entry outputLogEntry, function string) bool {
+	return function == "" || entry.Function == function || entry.Function == "function."+function
+}
+
+// adminLogs writes function output as json lines. The function query parameter
+// limits the output to one function, lines is the number of recent lines to
+// start with and output is followed until the client goes away or the master
+// shuts down, unless follow is false
+func (master *Master) adminLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	function := query.Get("function")
+	lines := 0
+	if v := query.Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "lines must be a positive number", http.StatusBadRequest)
+			return
+		}
+		lines = n
+	}
+	follow := query.Get("follow") != "false"
+
+	recent, ch, stop := master.output.follow()
+	defer stop()
+	// only the last lines that match the function are sent
+	matching := []outputLogEntry{}
+	for _, entry := range recent {
+		if matchesFunction(entry, function) {
+			matching = append(matching, entry)
+		}
+	}
+	if len(matching) > lines {
+		matching = matching[len(matching)-lines:]
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for _, entry := range matching {
+		_ = enc.Encode(entry)
+	}
+	flusher, _ := w.(http.Flusher)
+	if !follow {
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case entry := <-ch:
+			if !matchesFunction(entry, function) {
+				continue
+			}
+			if err := enc.Encode(entry); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			return
+		case <-master.output.closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"embly/pkg/tester"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOutputLog(te *testing.T) {
+	t := tester.New(te)
+	ol := newOutputLog()
+	for i := 0; i < maxRecentOutput+10; i++ {
+		ol.publish(outputLogEntry{Message: fmt.Sprint(i)})
+	}
+	recent, lines, stop := ol.follow()
+	t.Assert().Len(recent, maxRecentOutput)
+	t.Assert().Equal("10", recent[0].Message)
+
+	ol.publish(outputLogEntry{Message: "new"})
+	t.Assert().Equal("new", (<-lines).Message)
+	stop()
+	ol.publish(outputLogEntry{Message: "after stop"})
+	t.Assert().Len(lines, 0)
+
+	t.Assert().True(matchesFunction(outputLogEntry{Function: "function.foo"}, "foo"))
+	t.Assert().True(matchesFunction(outputLogEntry{Function: "function.foo"}, "function.foo"))
+	t.Assert().True(matchesFunction(outputLogEntry{Function: "function.foo"}, ""))
+	t.Assert().False(matchesFunction(outputLogEntry{Function: "function.foobar"}, "foo"))
+}
+
+func TestAdminLogs(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.ui = cli.NewMockUi()
+	foo := newOutputWriter("function.foo", 1, "stdout", m.publishOutput)
+	bar := newOutputWriter("function.bar", 2, "stderr", m.publishOutput)
+	foo.Write([]byte("one\ntwo\nthree\n"))
+	bar.Write([]byte("other\n"))
+
+	w := serve(m.adminHandler(), httptest.NewRequest("GET", "/logs?function=foo&lines=2&follow=false", nil))
+	t.Assert().Equal("application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := logLines(t, w.Body)
+	t.Assert().Len(lines, 2)
+	t.Assert().Equal("two", lines[0]["message"])
+	t.Assert().Equal("three", lines[1]["message"])
+	t.Assert().Equal("function.foo", lines[1]["function"])
+
+	w = serve(m.adminHandler(), httptest.NewRequest("GET", "/logs?lines=-1", nil))
+	t.Assert().Equal(http.StatusBadRequest, w.Code)
+
+	server := httptest.NewServer(m.adminHandler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/logs?function=bar&lines=1")
+	t.PanicOnErr(err)
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	next := func() (entry outputLogEntry) {
+		t.Assert().True(scanner.Scan())
+		t.PanicOnErr(json.Unmarshal(scanner.Bytes(), &entry))
+		return
+	}
+	t.Assert().Equal("other", next().Message)
+	foo.Write([]byte("skipped\n"))
+	bar.Write([]byte("followed\n"))
+	entry := next()
+	t.Assert().Equal("followed", entry.Message)
+	t.Assert().Equal("stderr", entry.Stream)
+	t.Assert().Equal(uint64(2), entry.Addr)
+
+	// followers stop when the master shuts down
+	t.PanicOnErr(m.Shutdown(context.Background()))
+	t.Assert().False(scanner.Scan())
+}
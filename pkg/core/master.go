@@ -2,21 +2,30 @@ package core
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"embly/pkg/build"
 	"embly/pkg/config"
 	comms_proto "embly/pkg/core/proto"
+	"embly/pkg/kv"
 	protoutil "embly/pkg/proto-util"
+	"embly/pkg/tracing"
 
 	"github.com/mitchellh/cli"
 	"github.com/pkg/errors"
@@ -28,11 +37,32 @@ type Master struct {
 	mutex          sync.Mutex
 	registry       sync.Map
 	functions      map[string]string
+	policies       map[string]*config.FunctionAllow
+	pools          map[string]*functionPool
 	ui             cli.Ui
 	databases      map[string]config.Database
+	kvStores       map[string]kv.Store
 	builder        *build.Builder
 	developmentRun bool
 	host           string
+	// jsonLog is set when logs are written as json
+	jsonLog *jsonLogger
+	metrics *masterMetrics
+	// output is the recent output of every function
+	output *outputLog
+	// tracer is nil when tracing isn't enabled
+	tracer *tracing.Tracer
+
+	// sockAddr is the unix socket that functions connect to, lock is held
+	// while listening on it
+	sockAddr     string
+	lock         *os.File
+	listener     net.Listener
+	shuttingDown int32
+	servers      []*http.Server
+	tcpListeners []net.Listener
+	tcpConns     map[net.Conn]struct{}
+	tcpWait      sync.WaitGroup
 }
 
 type funcOrGateway interface {
@@ -41,18 +71,53 @@ type funcOrGateway interface {
 
 // NewMaster creates a new master
 func NewMaster() *Master {
-	return &Master{
+	m := &Master{
 		registry:  sync.Map{},
 		functions: make(map[string]string),
+		policies:  make(map[string]*config.FunctionAllow),
+		pools:     make(map[string]*functionPool),
+		tcpConns:  make(map[net.Conn]struct{}),
+		sockAddr:  SockAddr,
 	}
+	m.metrics = newMasterMetrics(m)
+	m.output = newOutputLog()
+	return m
 }
 
-// SockAddr is the location of the embly unix socket
-var SockAddr = "/tmp/embly.sock"
+// SockDir is the directory of the embly unix sockets. It's only accessible by
+// the current user, so other users can't connect to a socket or replace it
+var SockDir = sockDir()
+
+func sockDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "embly")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("embly-%d", os.Getuid()))
+}
+
+// SockAddr is the default location of the embly unix socket, project sockets
+// are created in the same directory
+var SockAddr = filepath.Join(SockDir, "embly.sock")
+
+// ProjectSockAddr is the location of the unix socket for the project at
+// projectRoot, so that projects can run side by side
+func ProjectSockAddr(projectRoot string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(projectRoot)))
+	return filepath.Join(SockDir, fmt.Sprintf("embly-%x.sock", sum[:6]))
+}
+
+// ControlSockAddr is the location of the unix socket that serves the admin api
+// to commands like embly ps, it's next to sockAddr
+func ControlSockAddr(sockAddr string) string {
+	return strings.TrimSuffix(sockAddr, ".sock") + "-control.sock"
+}
 
 // EmblyWrapperExecutable is the executable we'll run
 var EmblyWrapperExecutable = "embly-wrapper"
 
+// ShutdownTimeout is how long in-flight requests are given to complete on shutdown
+var ShutdownTimeout = 10 * time.Second
+
 // WriteMessage ...
 func WriteMessage(consumer io.Writer, msg comms_proto.Message) (err error) {
 	return protoutil.WriteMessage(consumer, &msg)
@@ -66,29 +131,66 @@ func NextMessage(consumer io.Reader) (msg comms_proto.Message, err error) {
 
 // Function handles the state and connection for an embly function
 type Function struct {
-	addr     uint64
-	parent   uint64
-	cmd      *exec.Cmd
-	conn     net.Conn
-	exited   int32
-	connWait sync.WaitGroup
-	startup  comms_proto.Startup
+	master    *Master
+	name      string
+	addr      uint64
+	parent    uint64
+	cmd       *exec.Cmd
+	conn      net.Conn
+	exited    int32
+	connected chan struct{}
+	done      chan struct{}
+	startup   comms_proto.Startup
+	// started is when the function was created, used to time how long it
+	// takes to connect. It's set before the function is registered so it can
+	// be read without locking
+	started time.Time
+	// output is the function's stdout and stderr, flushed when it exits
+	output []*outputWriter
+
+	traceMutex sync.Mutex
+	trace      tracing.SpanContext
 }
 
 // RegisterConn registers a unix socket connection for this conn
 func (fn *Function) RegisterConn(conn net.Conn) {
 	fn.conn = conn
-	fn.connWait.Done()
+	close(fn.connected)
 }
 
-// HasConnOrWait will wait if there isn't a connection associated with this function yet
-func (fn *Function) HasConnOrWait() {
-	fn.connWait.Wait()
+// HasConnOrWait will wait if there isn't a connection associated with this
+// function yet. It returns an error if the function exits before it connects
+func (fn *Function) HasConnOrWait() error {
+	select {
+	case <-fn.connected:
+		return nil
+	case <-fn.done:
+		// the function might have connected just before it exited
+		select {
+		case <-fn.connected:
+			return nil
+		default:
+		}
+		return errors.Errorf("function %d exited before it connected", fn.addr)
+	}
+}
+
+// Exited reports whether the function's process has exited
+func (fn *Function) Exited() bool {
+	select {
+	case <-fn.done:
+		return true
+	default:
+		return false
+	}
 }
 
 // SendMsg sends a protobuf Message to this function
 func (fn *Function) sendMsg(msg comms_proto.Message) {
-	fn.HasConnOrWait()
+	if err := fn.HasConnOrWait(); err != nil {
+		log.Println(err)
+		return
+	}
 	if err := WriteMessage(fn.conn, msg); err != nil {
 		log.Println(err)
 	}
@@ -104,6 +206,7 @@ type Gateway struct {
 	master      *Master
 	childExited int32
 	msgChan     chan comms_proto.Message
+	created     time.Time
 }
 
 // NewGateway creates a new gateway
@@ -116,6 +219,7 @@ func (m *Master) NewGateway() *Gateway {
 		master:      m,
 		childExited: -1, // running
 		readCond:    sync.NewCond(&mu),
+		created:     time.Now(),
 	}
 	m.addFuncOrGateway(id, gat)
 	return gat
@@ -134,25 +238,77 @@ func (gat *Gateway) AttachFn(fn *Function) {
 // SendMsg sends a protobuf Message to this gateway
 func (gat *Gateway) sendMsg(msg comms_proto.Message) {
 	if msg.Exiting {
+		gat.readCond.L.Lock()
 		gat.childExited = msg.Exit
+		gat.readCond.L.Unlock()
 		gat.readCond.Broadcast()
 	} else if msg.Spawn != "" {
-		log.Fatal("unimplemented")
+		log.Println("gateways can't receive spawn messages")
 	} else {
+		gat.readCond.L.Lock()
 		gat.bufMutex.Lock()
 		gat.buf.Write(msg.Data)
 		gat.bufMutex.Unlock()
+		gat.readCond.L.Unlock()
 		gat.readCond.Broadcast()
 	}
 }
 
+// Close marks the gateway's child as exited so that any pending reads return io.EOF
+// once the buffer has been drained
+func (gat *Gateway) Close() error {
+	gat.readCond.L.Lock()
+	if gat.childExited == -1 {
+		gat.childExited = 0
+	}
+	gat.readCond.L.Unlock()
+	gat.readCond.Broadcast()
+	return nil
+}
+
+// exited marks the gateway's child as exited with code, unless the child
+// already sent its exit code
+func (gat *Gateway) exited(code int32) {
+	gat.readCond.L.Lock()
+	if gat.childExited == -1 {
+		gat.childExited = code
+	}
+	gat.readCond.L.Unlock()
+	gat.readCond.Broadcast()
+}
+
+// Kill closes the gateway so that pending reads return and stops its
+// function. A function that has connected is told that it's being stopped
+// first
+func (gat *Gateway) Kill() error {
+	gat.Close()
+	fn, ok := gat.master.getFuncOrGateway(gat.child).(*Function)
+	if !ok {
+		return errors.Errorf("gateway child %d doesn't exist", gat.child)
+	}
+	select {
+	case <-fn.connected:
+		fn.sendMsg(comms_proto.Message{
+			To:   gat.child,
+			From: gat.ID,
+			Kill: true,
+		})
+	default:
+	}
+	fn.Stop()
+	return nil
+}
+
 // Wait waits for bytes to be available to be read from the gateway
 func (gat *Gateway) Wait() {
 	gat.readCond.L.Lock()
-	gat.bufMutex.Lock()
-	len := gat.buf.Len()
-	gat.bufMutex.Unlock()
-	if len == 0 && gat.childExited == -1 {
+	for {
+		gat.bufMutex.Lock()
+		len := gat.buf.Len()
+		gat.bufMutex.Unlock()
+		if len != 0 || gat.childExited != -1 {
+			break
+		}
 		gat.readCond.Wait()
 	}
 	gat.readCond.L.Unlock()
@@ -177,11 +333,21 @@ func (gat *Gateway) Read(b []byte) (ln int, err error) {
 
 func (gat *Gateway) Write(b []byte) (ln int, err error) {
 	fn := gat.master.getFuncOrGateway(gat.child)
+	if fn == nil {
+		return 0, errors.Errorf("gateway child %d doesn't exist", gat.child)
+	}
 	msg := comms_proto.Message{
 		To:   gat.child,
 		From: gat.ID,
 		Data: b,
 	}
+	if child, ok := fn.(*Function); ok {
+		if err = child.HasConnOrWait(); err != nil {
+			return 0, err
+		}
+		msg.Traceparent = child.traceContext().Traceparent()
+	}
+	gat.master.metrics.messagesRouted.Inc()
 	fn.sendMsg(msg)
 	ln = len(b)
 	return
@@ -196,7 +362,32 @@ func envVars(values map[string]string) (out []string) {
 
 // Start starts a functions process
 func (fn *Function) Start() (err error) {
-	return fn.cmd.Start()
+	if err = fn.cmd.Start(); err != nil {
+		return
+	}
+	go func() {
+		// reap the process so that we don't leave zombies around
+		_ = fn.cmd.Wait()
+		for _, ow := range fn.output {
+			ow.Flush()
+		}
+		close(fn.done)
+		// functions that crash don't send an exit message, so the parent
+		// gateway would wait for them forever
+		if gat, ok := fn.master.getFuncOrGateway(fn.parent).(*Gateway); ok {
+			gat.exited(exitCode(fn.cmd.ProcessState))
+		}
+	}()
+	return nil
+}
+
+// exitCode is the exit code of a process, or 128 plus the signal that killed
+// it
+func exitCode(state *os.ProcessState) int32 {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return 128 + int32(ws.Signal())
+	}
+	return int32(state.ExitCode())
 }
 
 // Stop a functions process
@@ -216,11 +407,30 @@ func (m *Master) RegisterFunctionName(name, location string) {
 
 // SpawnFunction creates a starts a function with a provided address
 func (m *Master) SpawnFunction(name string, parent uint64, addr uint64, dbs []*comms_proto.DB) error {
+	return m.spawnFunction(name, parent, addr, dbs, tracing.SpanContext{})
+}
+
+// spawnFunction starts a function. If trace is valid the function's lifetime
+// and startup are recorded as spans and its messages are part of the trace
+func (m *Master) spawnFunction(name string, parent uint64, addr uint64, dbs []*comms_proto.DB, trace tracing.SpanContext) error {
 	fn, err := m.NewFunction(name, parent, &addr, dbs)
 	if err != nil {
 		return err
 	}
-	return fn.Start()
+	span := m.traceFunction(trace, fn)
+	if err = fn.Start(); err != nil {
+		span.SetError(err)
+		span.End()
+		return err
+	}
+	if span != nil {
+		m.traceSpawn(fn, span)
+		go func() {
+			<-fn.done
+			span.End()
+		}()
+	}
+	return nil
 }
 
 // StopFunction stops a function and removes it from the registry
@@ -233,7 +443,10 @@ func (m *Master) StopFunction(fn *Function) {
 func (m *Master) getFuncOrGateway(addr uint64) funcOrGateway {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	fog, _ := m.registry.Load(addr)
+	fog, ok := m.registry.Load(addr)
+	if !ok {
+		return nil
+	}
 	return fog.(funcOrGateway)
 }
 func (m *Master) addFuncOrGateway(addr uint64, fog funcOrGateway) {
@@ -260,20 +473,31 @@ func (m *Master) NewFunction(name string, parent uint64, addr *uint64, dbs []*co
 		addr = &v
 	}
 	fn = &Function{addr: *addr,
+		master:    m,
+		name:      name,
+		started:   time.Now(),
+		connected: make(chan struct{}),
+		done:      make(chan struct{}),
 		startup: comms_proto.Startup{
 			Module: location,
 			Addr:   *addr,
 			Parent: parent,
 			Dbs:    dbs,
 		}}
-	fn.connWait.Add(1)
 	cmd := exec.Command(EmblyWrapperExecutable)
-	label := fmt.Sprintf("[%s]: ", name)
-	cmd.Stdout = textio.NewPrefixWriter(os.Stdout, label)
-	cmd.Stderr = textio.NewPrefixWriter(os.Stderr, label)
+	stdout := newOutputWriter(name, *addr, "stdout", m.publishOutput)
+	stderr := newOutputWriter(name, *addr, "stderr", m.publishOutput)
+	fn.output = []*outputWriter{stdout, stderr}
+	if m.jsonLog != nil {
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	} else {
+		label := fmt.Sprintf("[%s]: ", name)
+		cmd.Stdout = io.MultiWriter(textio.NewPrefixWriter(os.Stdout, label), stdout)
+		cmd.Stderr = io.MultiWriter(textio.NewPrefixWriter(os.Stderr, label), stderr)
+	}
 	cmd.Env = envVars(map[string]string{
 		"EMBLY_ADDR":     fmt.Sprintf("%d", fn.addr),
-		"EMBLY_SOCKET":   SockAddr,
+		"EMBLY_SOCKET":   m.sockAddr,
 		"EMBLY_MODULE":   location,
 		"RUST_BACKTRACE": "ALL",
 		// "RUST_LOG":       "embly_wrapper",
@@ -284,19 +508,23 @@ func (m *Master) NewFunction(name string, parent uint64, addr *uint64, dbs []*co
 	return
 }
 
-func (m *Master) functionStartProcess(conn net.Conn) (err error) {
+func (m *Master) functionStartProcess(conn net.Conn) (fn *Function, err error) {
 	addrBytes := make([]byte, 8)
 	ln, err := conn.Read(addrBytes)
 	if err != nil {
-		return err
+		return
 	}
 	if ln != 8 {
-		log.Fatalf("incorrect read length %d", ln)
+		err = errors.Errorf("incorrect read length %d", ln)
+		return
 	}
 	addr := binary.LittleEndian.Uint64(addrBytes)
-	fnOrG := m.getFuncOrGateway(addr)
 	// we don't get unix messages from gateways
-	fn := fnOrG.(*Function)
+	fn, ok := m.getFuncOrGateway(addr).(*Function)
+	if !ok {
+		err = errors.Errorf("no function found for address %d", addr)
+		return
+	}
 
 	msg := comms_proto.Message{
 		YourAddress:   addr,
@@ -308,14 +536,18 @@ func (m *Master) functionStartProcess(conn net.Conn) (err error) {
 		log.Println(err)
 	}
 	fn.RegisterConn(conn)
+	m.metrics.functionSpawn.Observe(time.Since(fn.started).Seconds(), fn.name)
 	return
 }
 
 // Start starts listening on ths unix socket and will let fns communicate
 func (m *Master) Start() error {
 	return m.unixListen(func(conn net.Conn) {
-		if err := m.functionStartProcess(conn); err != nil {
+		defer conn.Close()
+		fn, err := m.functionStartProcess(conn)
+		if err != nil {
 			log.Println(err)
+			return
 		}
 
 		for {
@@ -325,40 +557,50 @@ func (m *Master) Start() error {
 					return
 				}
 				log.Println(err)
+				if _, ok := err.(net.Error); ok {
+					return
+				}
 				continue
 			}
+			if msg.From != fn.addr {
+				m.replyError(conn, msg, msg.To, comms_proto.ErrorCode_NOT_ALLOWED, errors.Errorf(
+					"function can't send messages from address %d", msg.From))
+				continue
+			}
+			trace := m.messageTraceContext(fn, msg)
 			if msg.Spawn != "" {
+				if err := m.canSpawn(fn, msg.Spawn); err != nil {
+					m.replyError(conn, msg, msg.SpawnAddress, comms_proto.ErrorCode_NOT_ALLOWED, err)
+					continue
+				}
 
 				// TODO: pass db access if it is allowed
 				if strings.HasPrefix(msg.Spawn, "embly/vinyl") {
 					if err := m.spawnVinyl(msg, conn); err != nil {
-						panic(err)
+						m.replyError(conn, msg, msg.SpawnAddress, comms_proto.ErrorCode_INVALID_SPAWN, err)
 					}
 					continue
 				}
 				if strings.HasPrefix(msg.Spawn, "embly/kv") {
 					if err := m.spawnKV(msg, conn); err != nil {
-						panic(err)
+						m.replyError(conn, msg, msg.SpawnAddress, comms_proto.ErrorCode_INVALID_SPAWN, err)
 					}
 					continue
 				}
 				// TODO: figure out function addressing, how will it work with slash "/embly/vinyl" namespacing
-				if err := m.SpawnFunction("function."+msg.Spawn, msg.From, msg.SpawnAddress, nil); err != nil {
-					recFn := m.getFuncOrGateway(msg.From)
-					if recFn != nil {
-						recFn.sendMsg(comms_proto.Message{
-							To:    msg.From,
-							From:  msg.SpawnAddress,
-							Error: 21,
-						})
-					}
+				if err := m.spawnFunction("function."+msg.Spawn, msg.From, msg.SpawnAddress, nil, trace); err != nil {
+					m.replyError(conn, msg, msg.SpawnAddress, comms_proto.ErrorCode_SPAWN_FAILED, err)
 				}
 				continue
 			}
-			// TODO: security: allows one to communicate with any function
 			recFn := m.getFuncOrGateway(msg.To)
 			if recFn == nil {
-				log.Fatal("fn not found for id ", msg.To)
+				m.replyError(conn, msg, msg.To, comms_proto.ErrorCode_ADDRESS_NOT_FOUND, errors.Errorf(
+					"no function or gateway found for address %d", msg.To))
+				continue
+			}
+			if err := m.canMessage(fn, recFn); err != nil {
+				m.replyError(conn, msg, msg.To, comms_proto.ErrorCode_NOT_ALLOWED, err)
 				continue
 			}
 
@@ -367,24 +609,187 @@ func (m *Master) Start() error {
 				// TODO: cleanup?
 			}
 
+			m.metrics.messagesRouted.Inc()
+			span := m.traceMessage(trace, &msg, recFn)
 			recFn.sendMsg(msg)
+			span.End()
 		}
 	})
 }
 
-func (m *Master) unixListen(handler func(net.Conn)) (err error) {
-	if err := os.RemoveAll(SockAddr); err != nil {
-		return err
+// replyError sends an error back to the function that sent msg. from is the address
+// the error appears to come from so the function can match it to a connection
+func (m *Master) replyError(conn net.Conn, msg comms_proto.Message, from uint64, code comms_proto.ErrorCode, err error) {
+	log.Println(err)
+	if err := WriteMessage(conn, comms_proto.Message{
+		Data:  []byte(err.Error()),
+		From:  from,
+		To:    msg.From,
+		Error: code,
+	}); err != nil {
+		log.Println(err)
+	}
+}
+
+func (m *Master) isShuttingDown() bool {
+	return atomic.LoadInt32(&m.shuttingDown) == 1
+}
+
+// listen takes the lock file and starts listening on the unix socket. The
+// listener is nil if the master was shut down before it started listening
+func (m *Master) listen() (l net.Listener, err error) {
+	// the socket is replaced while holding the mutex so that a master that
+	// was shut down before it started listening doesn't remove the socket of
+	// another master
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.isShuttingDown() || m.listener != nil {
+		return m.listener, nil
+	}
+	if filepath.Dir(m.sockAddr) == SockDir {
+		if err = makeSockDir(SockDir); err != nil {
+			return nil, err
+		}
+	}
+	lock, err := lockSocket(m.sockAddr)
+	if err != nil {
+		return nil, err
+	}
+	// we hold the lock, so anything at the socket address is left over from a
+	// master that is gone
+	if err = os.RemoveAll(m.sockAddr); err == nil {
+		l, err = net.Listen("unix", m.sockAddr)
 	}
-	l, err := net.Listen("unix", SockAddr)
 	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	m.lock, m.listener = lock, l
+	return l, nil
+}
+
+func (m *Master) unixListen(handler func(net.Conn)) (err error) {
+	l, err := m.listen()
+	if l == nil {
 		return err
 	}
 	for {
 		conn, err := l.Accept()
 		if err != nil {
+			if m.isShuttingDown() {
+				return nil
+			}
 			return err
 		}
 		go handler(conn)
 	}
 }
+
+func (m *Master) addServer(server *http.Server) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.servers = append(m.servers, server)
+}
+
+func (m *Master) addTCPListener(l net.Listener) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.tcpListeners = append(m.tcpListeners, l)
+}
+
+// addTCPConn tracks a gateway connection so that Shutdown can wait for it. It
+// returns false once shutdown has started and the connection should be closed
+func (m *Master) addTCPConn(conn net.Conn) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	// Shutdown takes the mutex after setting shuttingDown, so a connection is
+	// either added before it waits or refused
+	if m.isShuttingDown() {
+		return false
+	}
+	m.tcpConns[conn] = struct{}{}
+	m.tcpWait.Add(1)
+	return true
+}
+
+func (m *Master) removeTCPConn(conn net.Conn) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.tcpConns, conn)
+	m.tcpWait.Done()
+}
+
+// stopAll kills every running function and closes every gateway so that
+// anything waiting on a function returns
+func (m *Master) stopAll() {
+	m.registry.Range(func(key, value interface{}) bool {
+		switch fog := value.(type) {
+		case *Function:
+			fog.Stop()
+			m.registry.Delete(key)
+		case *Gateway:
+			fog.Close()
+		}
+		return true
+	})
+}
+
+// Shutdown stops gateways from accepting new connections and waits for in-flight
+// requests until the context is done. All functions are then killed and the unix
+// socket is closed.
+func (m *Master) Shutdown(ctx context.Context) (err error) {
+	atomic.StoreInt32(&m.shuttingDown, 1)
+	m.output.close()
+
+	m.mutex.Lock()
+	servers := m.servers
+	tcpListeners := m.tcpListeners
+	m.mutex.Unlock()
+
+	for _, l := range tcpListeners {
+		l.Close()
+	}
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				server.Close()
+			}
+		}(server)
+	}
+
+	tcpDone := make(chan struct{})
+	go func() {
+		m.tcpWait.Wait()
+		close(tcpDone)
+	}()
+	select {
+	case <-tcpDone:
+	case <-ctx.Done():
+		m.mutex.Lock()
+		for conn := range m.tcpConns {
+			conn.Close()
+		}
+		m.mutex.Unlock()
+	}
+	wg.Wait()
+	// if the deadline passed, requests that are still running will return once
+	// their functions are gone
+	m.stopAll()
+
+	m.mutex.Lock()
+	l, lock := m.listener, m.lock
+	m.mutex.Unlock()
+	// the socket and lock belong to another master if we never listened
+	if l == nil {
+		return
+	}
+	err = l.Close()
+	if rmErr := os.RemoveAll(m.sockAddr); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	lock.Close()
+	return
+}
@@ -2,12 +2,23 @@ package core
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"net"
+	"os"
 	"os/exec"
 	"testing"
+	"time"
 
+	"embly/pkg/config"
 	comms_proto "embly/pkg/core/proto"
+	protoutil "embly/pkg/proto-util"
 	"embly/pkg/tester"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/mitchellh/cli"
 )
 
 func init() {
@@ -24,6 +35,7 @@ func TestBasicMaster(te *testing.T) {
 	t := tester.New(te)
 	m := NewMaster()
 	go m.Start()
+	defer m.Shutdown(context.Background())
 
 	gat := m.NewGateway()
 
@@ -63,6 +75,27 @@ func TestBasicMaster(te *testing.T) {
 
 }
 
+func TestFunctionCrash(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	gat := m.NewGateway()
+	fn, err := m.NewFunction("foo", gat.ID, nil, nil)
+	t.PanicOnErr(err)
+	gat.AttachFn(fn)
+	t.PanicOnErr(fn.Start())
+	t.PanicOnErr(fn.HasConnOrWait())
+
+	// the function is killed without sending an exit message
+	fn.Stop()
+	_, err = gat.Read(make([]byte, 1))
+	t.Assert().Equal(io.EOF, err)
+	t.Assert().Equal(int32(killedExitCode), gat.childExited)
+}
+
 func TestFoo(t *testing.T) {
 	msg := comms_proto.Message{
 		Exit:  1,
@@ -80,3 +113,181 @@ func TestFoo(t *testing.T) {
 		t.Error("exit code should be 1")
 	}
 }
+
+func TestTCPGateway(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.ui = cli.NewMockUi()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	l, err := net.Listen("tcp", "localhost:0")
+	t.PanicOnErr(err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	m.host = "localhost"
+	t.PanicOnErr(m.launchTCPGateway(config.Gateway{
+		Type:     "tcp",
+		Port:     port,
+		Function: "foo",
+	}))
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	t.PanicOnErr(err)
+	defer conn.Close()
+
+	sending := []byte("it's lunchtime")
+	_, err = conn.Write(sending)
+	t.Assert().NoError(err)
+
+	buf := make([]byte, len(sending))
+	_, err = io.ReadFull(conn, buf)
+	t.Assert().NoError(err)
+	t.Assert().Equal(sending, buf)
+}
+
+func TestShutdown(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.ui = cli.NewMockUi()
+	masterErr := make(chan error, 1)
+	go func() {
+		masterErr <- m.Start()
+	}()
+	m.functions["foo"] = ""
+
+	l, err := net.Listen("tcp", "localhost:0")
+	t.PanicOnErr(err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	m.host = "localhost"
+	t.PanicOnErr(m.launchTCPGateway(config.Gateway{
+		Type:     "tcp",
+		Port:     port,
+		Function: "foo",
+	}))
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	t.PanicOnErr(err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("hi"))
+	t.PanicOnErr(err)
+	_, err = io.ReadFull(conn, make([]byte, 2))
+	t.PanicOnErr(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	t.Assert().NoError(m.Shutdown(ctx))
+	t.Assert().NoError(<-masterErr)
+
+	// the open connection is closed once the deadline passes
+	_, err = conn.Read(make([]byte, 1))
+	t.Assert().Error(err)
+	m.registry.Range(func(key, value interface{}) bool {
+		_, isFunction := value.(*Function)
+		t.Assert().False(isFunction, "all functions should be stopped")
+		return true
+	})
+	_, err = os.Stat(SockAddr)
+	t.Assert().True(os.IsNotExist(err), "socket should be removed")
+	_, err = net.Dial("tcp", fmt.Sprintf("localhost:%d", port))
+	t.Assert().Error(err)
+
+	// connections that race with shutdown are refused
+	t.Assert().False(m.addTCPConn(conn))
+}
+
+// connectFakeFunction connects to the master's socket as if it were the wrapper
+// process for fn
+func connectFakeFunction(t tester.Tester, fn *Function) net.Conn {
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("unix", SockAddr); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	t.PanicOnErr(err)
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, fn.addr)
+	_, err = conn.Write(b)
+	t.PanicOnErr(err)
+	startup, err := NextMessage(conn)
+	t.PanicOnErr(err)
+	t.Assert().Equal(fn.addr, startup.YourAddress)
+	return conn
+}
+
+// fakeFunctionIO reads and writes http protos on a fake function's connection
+type fakeFunctionIO struct {
+	t    tester.Tester
+	conn net.Conn
+	fn   *Function
+	gat  *Gateway
+}
+
+// startFakeFunction creates a function attached to a new gateway and connects
+// to the master as that function
+func startFakeFunction(t tester.Tester, m *Master, name string) fakeFunctionIO {
+	gat := m.NewGateway()
+	fn, err := m.NewFunction(name, gat.ID, nil, nil)
+	t.PanicOnErr(err)
+	gat.AttachFn(fn)
+	conn := connectFakeFunction(t, fn)
+	return fakeFunctionIO{t: t, conn: conn, fn: fn, gat: gat}
+}
+
+func (f fakeFunctionIO) next(pb proto.Message) {
+	msg, err := NextMessage(f.conn)
+	f.t.PanicOnErr(err)
+	f.t.PanicOnErr(protoutil.NextMessage(bytes.NewReader(msg.Data), pb))
+}
+
+func (f fakeFunctionIO) send(pb proto.Message) {
+	var buf bytes.Buffer
+	f.t.PanicOnErr(protoutil.WriteMessage(&buf, pb))
+	f.t.PanicOnErr(WriteMessage(f.conn, comms_proto.Message{
+		To:   f.gat.ID,
+		From: f.fn.addr,
+		Data: buf.Bytes(),
+	}))
+}
+
+func TestErrorReplies(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	fn, err := m.NewFunction("foo", 1, nil, nil)
+	t.PanicOnErr(err)
+	conn := connectFakeFunction(t, fn)
+	defer conn.Close()
+
+	for _, tc := range []struct {
+		msg  comms_proto.Message
+		code comms_proto.ErrorCode
+	}{
+		{comms_proto.Message{To: 12345, From: fn.addr, Data: []byte("hi")},
+			comms_proto.ErrorCode_ADDRESS_NOT_FOUND},
+		{comms_proto.Message{Spawn: "embly/kv/nope", SpawnAddress: 2, From: fn.addr},
+			comms_proto.ErrorCode_INVALID_SPAWN},
+		{comms_proto.Message{Spawn: "embly/vinyl/nope/connect", SpawnAddress: 3, From: fn.addr},
+			comms_proto.ErrorCode_INVALID_SPAWN},
+		{comms_proto.Message{Spawn: "nope", SpawnAddress: 4, From: fn.addr},
+			comms_proto.ErrorCode_SPAWN_FAILED},
+		{comms_proto.Message{To: 12345, From: 6789},
+			comms_proto.ErrorCode_NOT_ALLOWED},
+	} {
+		t.PanicOnErr(WriteMessage(conn, tc.msg))
+		reply, err := NextMessage(conn)
+		t.PanicOnErr(err)
+		t.Assert().Equal(tc.code, reply.Error)
+		t.Assert().Equal(tc.msg.From, reply.To)
+		t.Assert().NotEmpty(reply.Data)
+	}
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"embly/pkg/metrics"
+)
+
+// masterMetrics are the metrics recorded by a master, they're served by the
+// admin listener
+type masterMetrics struct {
+	registry *metrics.Registry
+
+	httpRequests   *metrics.Counter
+	httpDuration   *metrics.Histogram
+	functionSpawn  *metrics.Histogram
+	messagesRouted *metrics.Counter
+	kvOperations   *metrics.Counter
+	vinylDuration  *metrics.Histogram
+}
+
+func newMasterMetrics(m *Master) *masterMetrics {
+	r := metrics.NewRegistry()
+	r.NewGaugeFunc("embly_functions",
+		"Number of function instances in the registry.",
+		func() float64 { return float64(m.countFunctions()) })
+	return &masterMetrics{
+		registry: r,
+		httpRequests: r.NewCounter("embly_http_requests_total",
+			"Requests handled by http gateways by route and status.",
+			"gateway", "route", "status"),
+		httpDuration: r.NewHistogram("embly_http_request_duration_seconds",
+			"Time taken to respond to requests to http gateways by route.",
+			metrics.DefaultBuckets, "gateway", "route"),
+		functionSpawn: r.NewHistogram("embly_function_spawn_duration_seconds",
+			"Time from starting a function's process until it connects to the master.",
+			metrics.DefaultBuckets, "function"),
+		messagesRouted: r.NewCounter("embly_messages_routed_total",
+			"Messages routed by the master between functions, gateways and stores."),
+		kvOperations: r.NewCounter("embly_kv_operations_total",
+			"KV commands processed by namespace, command and result.",
+			"namespace", "command", "result"),
+		vinylDuration: r.NewHistogram("embly_vinyl_request_duration_seconds",
+			"Time taken by vinyl database requests by database.",
+			metrics.DefaultBuckets, "database"),
+	}
+}
+
+func (m *Master) countFunctions() (count int) {
+	m.registry.Range(func(_, value interface{}) bool {
+		if _, ok := value.(*Function); ok {
+			count++
+		}
+		return true
+	})
+	return
+}
+
+// instrument records the status and duration of requests to a route
+func (mm *masterMetrics) instrument(port int, route string, h http.Handler) http.Handler {
+	gateway := strconv.Itoa(port)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger := makeLogger(w)
+		h.ServeHTTP(logger, r)
+		mm.httpRequests.Inc(gateway, route, strconv.Itoa(logger.Status()))
+		mm.httpDuration.Observe(time.Since(start).Seconds(), gateway, route)
+	})
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"embly/pkg/tester"
+)
+
+func TestMasterMetrics(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.functions["foo"] = ""
+	_, err := m.NewFunction("foo", 1, nil, nil)
+	t.PanicOnErr(err)
+	m.NewGateway()
+
+	h := m.metrics.instrument(8080, "/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	serve(h, httptest.NewRequest("GET", "/users/1", nil))
+	serve(h, httptest.NewRequest("GET", "/users/2", nil))
+
+	w := serve(m.adminHandler(), httptest.NewRequest("GET", "/metrics", nil))
+	t.Assert().Equal(http.StatusOK, w.Code)
+	body := w.Body.String()
+	t.Assert().Contains(body, "\nembly_functions 1\n")
+	t.Assert().Contains(body, `embly_http_requests_total{gateway="8080",route="/users/:id",status="404"} 2`)
+	t.Assert().Contains(body, `embly_http_request_duration_seconds_count{gateway="8080",route="/users/:id"} 2`)
+}
@@ -0,0 +1,413 @@
+package core
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"embly/pkg/config"
+)
+
+// middleware wraps a route handler with the middleware configured for the
+// route. Requests pass through the ip filter, the rate limit, cors, auth and
+// compression in that order
+func middleware(mw config.Middleware, h http.Handler) http.Handler {
+	if mw.Compress != nil {
+		h = compressHandler(*mw.Compress, h)
+	}
+	if mw.BasicAuth != nil || mw.BearerAuth != nil {
+		h = authHandler(mw.BasicAuth, mw.BearerAuth, h)
+	}
+	if mw.CORS != nil {
+		h = corsHandler(*mw.CORS, h)
+	}
+	if mw.RateLimit != nil {
+		h = rateLimitHandler(*mw.RateLimit, h)
+	}
+	if mw.IP != nil {
+		h = ipFilterHandler(*mw.IP, h)
+	}
+	return h
+}
+
+// hostIP removes the port from an address
+func hostIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
+
+// parseNets parses the ips and cidr ranges of a config that ParseConfig
+// checked
+func parseNets(addrs []string) (nets []*net.IPNet) {
+	for _, addr := range addrs {
+		ipNet, _ := config.ParseCIDR(addr)
+		nets = append(nets, ipNet)
+	}
+	return
+}
+
+func inNets(ip string, nets []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	for _, n := range nets {
+		if parsed != nil && n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+type clientIPKey struct{}
+
+// clientIPHandler finds the address of the client of each request. It's the
+// connection's address unless that's a trusted proxy, then the forwarding
+// headers are read back from the nearest hop to the first address that isn't
+// a trusted proxy
+func clientIPHandler(trustedProxies []string, h http.Handler) http.Handler {
+	if len(trustedProxies) == 0 {
+		return h
+	}
+	trusted := parseNets(trustedProxies)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := hostIP(r.RemoteAddr)
+		if inNets(ip, trusted) {
+			hops := forwardedIPs(r)
+			for i := len(hops) - 1; i >= 0; i-- {
+				if ip = hops[i]; !inNets(ip, trusted) {
+					break
+				}
+			}
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIPKey{}, ip)))
+	})
+}
+
+// clientIP is the address found by clientIPHandler, or the connection's
+// address if the gateway doesn't trust any proxies
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return hostIP(r.RemoteAddr)
+}
+
+func ipFilterHandler(filter config.IPFilter, h http.Handler) http.Handler {
+	allow, deny := parseNets(filter.Allow), parseNets(filter.Deny)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		if net.ParseIP(ip) == nil || inNets(ip, deny) || len(allow) > 0 && !inNets(ip, allow) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitSweepInterval is how often buckets that have refilled are removed
+var rateLimitSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket per client. Each bucket holds up to burst
+// tokens and gains a token every interval
+type rateLimiter struct {
+	interval time.Duration
+	burst    float64
+
+	mutex     sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(rl config.RateLimit) *rateLimiter {
+	burst := rl.Burst
+	if burst == 0 {
+		burst = rl.Requests
+	}
+	return &rateLimiter{
+		interval: rl.Interval(),
+		burst:    float64(burst),
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+// refill must be called while holding the mutex
+func (rl *rateLimiter) refill(b *tokenBucket, now time.Time) {
+	b.tokens = math.Min(rl.burst, b.tokens+float64(now.Sub(b.last))/float64(rl.interval))
+	b.last = now
+}
+
+// allow takes a token from key's bucket. If the bucket is empty it returns how
+// long until a token is available
+func (rl *rateLimiter) allow(key string, now time.Time) (ok bool, retryAfter time.Duration) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if now.Sub(rl.lastSweep) > rateLimitSweepInterval {
+		for k, b := range rl.buckets {
+			if rl.refill(b, now); b.tokens >= rl.burst {
+				delete(rl.buckets, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+	b, exists := rl.buckets[key]
+	if !exists {
+		b = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[key] = b
+	}
+	rl.refill(b, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(rl.interval))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func rateLimitHandler(rl config.RateLimit, h http.Handler) http.Handler {
+	limiter := newRateLimiter(rl)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := limiter.allow(clientIP(r), time.Now()); !ok {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func corsHandler(cors config.CORS, h http.Handler) http.Handler {
+	allowed := func(origin string) bool {
+		for _, o := range cors.Origins {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if origin == "" || !allowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if contains(cors.Origins, "*") && !cors.Credentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cors.Credentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if len(cors.ExposeHeaders) > 0 {
+				header.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposeHeaders, ", "))
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if len(cors.Methods) > 0 {
+			header.Set("Access-Control-Allow-Methods", strings.Join(cors.Methods, ", "))
+		} else {
+			header.Set("Access-Control-Allow-Methods", r.Header.Get("Access-Control-Request-Method"))
+		}
+		if len(cors.Headers) > 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(cors.Headers, ", "))
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if cors.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(cors.MaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func equalSecret(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// authHandler requires basic or bearer credentials, when both are configured
+// either one is accepted
+func authHandler(basic *config.BasicAuth, bearer *config.BearerAuth, h http.Handler) http.Handler {
+	authorized := func(r *http.Request) bool {
+		if basic != nil {
+			if user, password, ok := r.BasicAuth(); ok {
+				if expected, exists := basic.Users[user]; exists && equalSecret(password, expected) {
+					return true
+				}
+			}
+		}
+		if bearer != nil {
+			auth := r.Header.Get("Authorization")
+			if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+				token := strings.TrimSpace(auth[7:])
+				for _, t := range bearer.Tokens {
+					if equalSecret(token, t) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorized(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if basic != nil {
+			realm := basic.Realm
+			if realm == "" {
+				realm = "embly"
+			}
+			w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s", charset="UTF-8"`, realm))
+		}
+		if bearer != nil {
+			w.Header().Add("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	})
+}
+
+// defaultCompressTypes are the content types that are compressed when a
+// compress block doesn't list any
+var defaultCompressTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+func compressHandler(c config.Compress, h http.Handler) http.Handler {
+	types := c.Types
+	if len(types) == 0 {
+		types = defaultCompressTypes
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsEncoding(r.Header.Get("Accept-Encoding"), "gzip") || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w, types: types}
+		defer gw.Close()
+		h.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsEncoding checks an Accept-Encoding header for an encoding that isn't
+// refused with a q of 0
+func acceptsEncoding(accepted, encoding string) bool {
+	for _, enc := range strings.Split(accepted, ",") {
+		parts := strings.Split(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(parts[0], encoding) {
+			return len(parts) == 1 || strings.TrimSpace(parts[1]) != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter decides whether to compress when the header is written.
+// Responses that are already encoded, have no body or have a content type that
+// isn't listed are passed through
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	types       []string
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (gw *gzipResponseWriter) compressible(status int) bool {
+	header := gw.Header()
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified ||
+		header.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range gw.types {
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if gw.wroteHeader {
+		return
+	}
+	gw.wroteHeader = true
+	if gw.compressible(status) {
+		gw.Header().Set("Content-Encoding", "gzip")
+		gw.Header().Del("Content-Length")
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
+	}
+	gw.ResponseWriter.WriteHeader(status)
+}
+
+func (gw *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !gw.wroteHeader {
+		if gw.Header().Get("Content-Type") == "" {
+			gw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		gw.WriteHeader(http.StatusOK)
+	}
+	if gw.gz != nil {
+		return gw.gz.Write(b)
+	}
+	return gw.ResponseWriter.Write(b)
+}
+
+// Flush writes compressed data so that streamed responses aren't held back
+func (gw *gzipResponseWriter) Flush() {
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	if f, ok := gw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer so that http.ResponseController can reach
+// it
+func (gw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// Close writes the end of the compressed stream
+func (gw *gzipResponseWriter) Close() error {
+	if gw.gz == nil {
+		return nil
+	}
+	return gw.gz.Close()
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"embly/pkg/config"
+	"embly/pkg/tester"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok ok ok ok"))
+})
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIPFilter(te *testing.T) {
+	t := tester.New(te)
+	h := middleware(config.Middleware{IP: &config.IPFilter{
+		Allow: []string{"10.0.0.0/8", "::1"},
+		Deny:  []string{"10.0.0.5"},
+	}}, okHandler)
+	for addr, status := range map[string]int{
+		"10.1.2.3:1234": http.StatusOK,
+		"[::1]:1234":    http.StatusOK,
+		"10.0.0.5:1234": http.StatusForbidden,
+		"192.0.2.1:80":  http.StatusForbidden,
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		t.Assert().Equal(status, serve(h, r).Code, addr)
+	}
+
+	// forwarding headers from clients aren't trusted
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:80"
+	r.Header.Set("X-Forwarded-For", "10.1.2.3")
+	t.Assert().Equal(http.StatusForbidden, serve(h, r).Code)
+
+	// but they are from trusted proxies
+	proxied := clientIPHandler([]string{"10.1.1.1"}, h)
+	r = httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.1.1.1:1234"
+	r.Header.Set("X-Forwarded-For", "10.0.0.5")
+	t.Assert().Equal(http.StatusForbidden, serve(proxied, r).Code)
+	r.Header.Set("X-Forwarded-For", "10.1.2.3")
+	t.Assert().Equal(http.StatusOK, serve(proxied, r).Code)
+
+	// a client can't get past the deny list by spoofing the header the proxy
+	// appends to
+	r = httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.1.1.1:1234"
+	r.Header.Set("X-Forwarded-For", "10.1.2.3, 10.0.0.5")
+	t.Assert().Equal(http.StatusForbidden, serve(proxied, r).Code)
+	r = httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.5:1234"
+	r.Header.Set("X-Real-IP", "10.1.2.3")
+	t.Assert().Equal(http.StatusForbidden, serve(proxied, r).Code)
+}
+
+func TestClientIP(te *testing.T) {
+	t := tester.New(te)
+	var ip string
+	h := clientIPHandler([]string{"10.0.0.0/8", "fd00::/8"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip = clientIP(r)
+	}))
+	for _, tc := range []struct {
+		remoteAddr, header, value, ip string
+	}{
+		{"192.0.2.1:80", "X-Forwarded-For", "203.0.113.9", "192.0.2.1"},
+		{"10.0.0.1:80", "", "", "10.0.0.1"},
+		{"10.0.0.1:80", "X-Forwarded-For", "203.0.113.9", "203.0.113.9"},
+		// trusted proxies earlier in the chain are skipped
+		{"10.0.0.1:80", "X-Forwarded-For", "198.51.100.7, 203.0.113.9, 10.0.0.2", "203.0.113.9"},
+		{"[fd00::1]:80", "X-Real-IP", "203.0.113.9", "203.0.113.9"},
+		{"10.0.0.1:80", "Forwarded", `for=198.51.100.7, for="[2001:db8::1]:4711";proto=https`, "2001:db8::1"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remoteAddr
+		if tc.header != "" {
+			r.Header.Set(tc.header, tc.value)
+		}
+		serve(h, r)
+		t.Assert().Equal(tc.ip, ip, tc.value)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:80"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9")
+	t.Assert().Equal("10.0.0.1", clientIP(r), "without trusted proxies headers are ignored")
+}
+
+func TestRateLimiter(te *testing.T) {
+	t := tester.New(te)
+	rl := newRateLimiter(config.RateLimit{Requests: 2, Per: "1s", Burst: 3})
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		ok, _ := rl.allow("a", now)
+		t.Assert().True(ok)
+	}
+	ok, retryAfter := rl.allow("a", now)
+	t.Assert().False(ok)
+	t.Assert().Equal(500*time.Millisecond, retryAfter)
+
+	// other clients have their own bucket
+	ok, _ = rl.allow("b", now)
+	t.Assert().True(ok)
+
+	ok, _ = rl.allow("a", now.Add(500*time.Millisecond))
+	t.Assert().True(ok)
+
+	// full buckets are removed
+	rl.allow("a", now.Add(time.Hour))
+	t.Assert().Len(rl.buckets, 1)
+
+	h := middleware(config.Middleware{RateLimit: &config.RateLimit{Requests: 1, Per: "1m"}}, okHandler)
+	t.Assert().Equal(http.StatusOK, serve(h, httptest.NewRequest("GET", "/", nil)).Code)
+	w := serve(h, httptest.NewRequest("GET", "/", nil))
+	t.Assert().Equal(http.StatusTooManyRequests, w.Code)
+	t.Assert().Equal("60", w.Header().Get("Retry-After"))
+}
+
+func TestCORS(te *testing.T) {
+	t := tester.New(te)
+	h := middleware(config.Middleware{CORS: &config.CORS{
+		Origins:       []string{"https://app.example.com"},
+		Headers:       []string{"Content-Type"},
+		ExposeHeaders: []string{"X-Total"},
+		Credentials:   true,
+		MaxAge:        600,
+	}}, okHandler)
+
+	r := httptest.NewRequest("OPTIONS", "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	r.Header.Set("Access-Control-Request-Method", "PUT")
+	w := serve(h, r)
+	t.Assert().Equal(http.StatusNoContent, w.Code)
+	t.Assert().Equal("https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	t.Assert().Equal("true", w.Header().Get("Access-Control-Allow-Credentials"))
+	t.Assert().Equal("PUT", w.Header().Get("Access-Control-Allow-Methods"))
+	t.Assert().Equal("Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+	t.Assert().Equal("600", w.Header().Get("Access-Control-Max-Age"))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	w = serve(h, r)
+	t.Assert().Equal("ok ok ok ok", w.Body.String())
+	t.Assert().Equal("X-Total", w.Header().Get("Access-Control-Expose-Headers"))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Origin", "https://evil.example.com")
+	w = serve(h, r)
+	t.Assert().Equal("", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestAuth(te *testing.T) {
+	t := tester.New(te)
+	h := middleware(config.Middleware{
+		BasicAuth:  &config.BasicAuth{Users: map[string]string{"alice": "secret"}},
+		BearerAuth: &config.BearerAuth{Tokens: []string{"token"}},
+	}, okHandler)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := serve(h, r)
+	t.Assert().Equal(http.StatusUnauthorized, w.Code)
+	t.Assert().Equal([]string{`Basic realm="embly", charset="UTF-8"`, "Bearer"}, w.Header()["Www-Authenticate"])
+
+	r.SetBasicAuth("alice", "secret")
+	t.Assert().Equal(http.StatusOK, serve(h, r).Code)
+	r.SetBasicAuth("alice", "wrong")
+	t.Assert().Equal(http.StatusUnauthorized, serve(h, r).Code)
+
+	r.Header.Set("Authorization", "Bearer token")
+	t.Assert().Equal(http.StatusOK, serve(h, r).Code)
+	r.Header.Set("Authorization", "Bearer nope")
+	t.Assert().Equal(http.StatusUnauthorized, serve(h, r).Code)
+}
+
+func TestCompress(te *testing.T) {
+	t := tester.New(te)
+	h := middleware(config.Middleware{Compress: &config.Compress{}}, okHandler)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", "br, gzip")
+	w := serve(h, r)
+	t.Assert().Equal("gzip", w.Header().Get("Content-Encoding"))
+	gz, err := gzip.NewReader(w.Body)
+	t.PanicOnErr(err)
+	body, err := ioutil.ReadAll(gz)
+	t.PanicOnErr(err)
+	t.Assert().Equal("ok ok ok ok", string(body))
+
+	r.Header.Set("Accept-Encoding", "gzip;q=0")
+	w = serve(h, r)
+	t.Assert().Equal("", w.Header().Get("Content-Encoding"))
+	t.Assert().Equal("ok ok ok ok", w.Body.String())
+
+	image := middleware(config.Middleware{Compress: &config.Compress{}},
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png"))
+		}))
+	r.Header.Set("Accept-Encoding", "gzip")
+	w = serve(image, r)
+	t.Assert().Equal("", w.Header().Get("Content-Encoding"))
+	t.Assert().Equal("png", w.Body.String())
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"time"
 )
 
 func main() {
@@ -24,7 +25,15 @@ func main() {
 	if emblySocket == "" {
 		panic("need socket")
 	}
-	conn, err := net.Dial("unix", emblySocket)
+	// tests start the master in a goroutine, so it might not be listening yet
+	var conn net.Conn
+	var err error
+	for i := 0; i < 50; i++ {
+		if conn, err = net.Dial("unix", emblySocket); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
 	if err != nil {
 		panic(err)
 	}
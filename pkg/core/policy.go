@@ -0,0 +1,80 @@
+package core
+
+import (
+	"strings"
+
+	"embly/pkg/config"
+
+	"github.com/pkg/errors"
+)
+
+// SetFunctionPolicy limits what a function can spawn and message. Functions
+// without a policy can reach everything
+func (m *Master) SetFunctionPolicy(name string, allow *config.FunctionAllow) {
+	m.policies[name] = allow
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// canSpawn checks a spawn path against the spawning function's policy
+func (m *Master) canSpawn(fn *Function, spawn string) error {
+	allow := m.policies[fn.name]
+	if allow == nil {
+		return nil
+	}
+	if strings.HasPrefix(spawn, "embly/vinyl") {
+		parts := strings.Split(spawn, "/")
+		if len(parts) > 2 && contains(allow.Databases, parts[2]) {
+			return nil
+		}
+		return errors.Errorf(`%s is not allowed to access "%s"`, fn.name, spawn)
+	}
+	if strings.HasPrefix(spawn, "embly/kv") {
+		namespace, _, err := parseKVSpawn(spawn)
+		if err == nil && contains(allow.KV, namespace) {
+			return nil
+		}
+		return errors.Errorf(`%s is not allowed to access "%s"`, fn.name, spawn)
+	}
+	if contains(allow.Functions, "function."+spawn) {
+		return nil
+	}
+	return errors.Errorf(`%s is not allowed to spawn "%s"`, fn.name, spawn)
+}
+
+// canMessage checks if a function can send a message to a function, gateway or
+// database connection. Functions can always message their parent and their
+// children
+func (m *Master) canMessage(fn *Function, to funcOrGateway) error {
+	allow := m.policies[fn.name]
+	if allow == nil {
+		return nil
+	}
+	switch recv := to.(type) {
+	case *Function:
+		if recv.addr == fn.parent || recv.parent == fn.addr || contains(allow.Functions, recv.name) {
+			return nil
+		}
+		return errors.Errorf(`%s is not allowed to message "%s"`, fn.name, recv.name)
+	case *Gateway:
+		if recv.ID == fn.parent {
+			return nil
+		}
+	case *KV:
+		if recv.owner == fn.addr {
+			return nil
+		}
+	case *Vinyl:
+		if recv.owner == fn.addr {
+			return nil
+		}
+	}
+	return errors.Errorf("%s is not allowed to message this address", fn.name)
+}
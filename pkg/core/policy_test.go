@@ -0,0 +1,39 @@
+package core
+
+import (
+	"testing"
+
+	"embly/pkg/config"
+	"embly/pkg/tester"
+)
+
+func TestFunctionPolicy(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	m.SetFunctionPolicy("function.foo", &config.FunctionAllow{
+		Functions: []string{"function.bar"},
+		Databases: []string{"main"},
+		KV:        []string{"default"},
+	})
+
+	foo := &Function{name: "function.foo", addr: 1, parent: 2}
+	unrestricted := &Function{name: "function.baz", addr: 5}
+
+	t.Assert().NoError(m.canSpawn(foo, "bar"))
+	t.Assert().NoError(m.canSpawn(foo, "embly/vinyl/main/connect"))
+	t.Assert().NoError(m.canSpawn(foo, "embly/kv/get"))
+	t.ErrorContains(m.canSpawn(foo, "baz"), "not allowed")
+	t.ErrorContains(m.canSpawn(foo, "embly/vinyl/other/request"), "not allowed")
+	t.Assert().NoError(m.canSpawn(foo, "embly/kv/default/set"))
+	t.ErrorContains(m.canSpawn(foo, "embly/kv/sessions/get"), "not allowed")
+	t.Assert().NoError(m.canSpawn(unrestricted, "foo"))
+
+	t.Assert().NoError(m.canMessage(foo, &Gateway{ID: 2}))
+	t.Assert().NoError(m.canMessage(foo, &Function{name: "function.baz", addr: 3, parent: 1}))
+	t.Assert().NoError(m.canMessage(foo, &Function{name: "function.bar", addr: 4}))
+	t.Assert().NoError(m.canMessage(foo, &KV{owner: 1}))
+	t.ErrorContains(m.canMessage(foo, &Gateway{ID: 3}), "not allowed")
+	t.ErrorContains(m.canMessage(foo, &Function{name: "function.baz", addr: 5}), "not allowed")
+	t.ErrorContains(m.canMessage(foo, &Vinyl{owner: 5}), "not allowed")
+	t.Assert().NoError(m.canMessage(unrestricted, &Function{name: "function.foo", addr: 1}))
+}
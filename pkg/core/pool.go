@@ -0,0 +1,256 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"embly/pkg/tracing"
+
+	"github.com/pkg/errors"
+)
+
+// pooledFunction is a started function along with the gateway that is its parent
+type pooledFunction struct {
+	gat *Gateway
+	fn  *Function
+}
+
+// functionPool keeps started and connected functions ready to be handed out
+// to gateways. Functions are single use, once a function has been checked out
+// and returned it is stopped and the pool starts a new one in its place.
+type functionPool struct {
+	master *Master
+	name   string
+
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	min     int
+	max     int
+	idle    []pooledFunction
+	warming int
+	live    int
+	// generation is incremented on every flush so that functions that were
+	// warming during a flush are discarded
+	generation int
+}
+
+func newFunctionPool(m *Master, name string) *functionPool {
+	p := &functionPool{
+		master: m,
+		name:   name,
+	}
+	p.cond = sync.NewCond(&p.mutex)
+	return p
+}
+
+func (p *functionPool) startFunction() (pf pooledFunction, err error) {
+	pf.gat = p.master.NewGateway()
+	if pf.fn, err = p.master.NewFunction(p.name, pf.gat.ID, nil, nil); err != nil {
+		p.master.RemoveGateway(pf.gat)
+		return
+	}
+	pf.gat.AttachFn(pf.fn)
+	if err = pf.fn.Start(); err != nil {
+		p.master.delFuncOrGateway(pf.fn.addr)
+		p.master.RemoveGateway(pf.gat)
+	}
+	return
+}
+
+func (p *functionPool) stopFunction(pf pooledFunction) {
+	p.master.StopFunction(pf.fn)
+	p.master.RemoveGateway(pf.gat)
+}
+
+// atCapacity must be called while holding the pool mutex
+func (p *functionPool) atCapacity() bool {
+	return p.max > 0 && p.live >= p.max
+}
+
+// fill starts functions until there are at least min idle or warming functions
+func (p *functionPool) fill() {
+	if p.master.isShuttingDown() {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for len(p.idle)+p.warming < p.min && !p.atCapacity() {
+		p.warming++
+		p.live++
+		go p.warm(p.generation)
+	}
+}
+
+// warm starts a function and waits for it to connect before adding it to the
+// idle list
+func (p *functionPool) warm(generation int) {
+	pf, err := p.startFunction()
+	if err == nil {
+		select {
+		case <-pf.fn.connected:
+		case <-pf.fn.done:
+			err = errors.Errorf("function %s exited before connecting", p.name)
+			p.stopFunction(pf)
+		}
+	}
+
+	p.mutex.Lock()
+	p.warming--
+	if err != nil || generation != p.generation || p.master.isShuttingDown() {
+		p.live--
+		p.mutex.Unlock()
+		p.cond.Signal()
+		if err != nil && p.master.ui != nil {
+			p.master.ui.Error(fmt.Sprintf("error starting pooled function: %s", err))
+		} else if err == nil {
+			p.stopFunction(pf)
+		}
+		return
+	}
+	p.idle = append(p.idle, pf)
+	p.mutex.Unlock()
+	p.cond.Signal()
+}
+
+// get checks out a function, waiting for one to be available if the pool is at
+// its maximum size
+func (p *functionPool) get() (pf pooledFunction, err error) {
+	p.mutex.Lock()
+	for {
+		for len(p.idle) > 0 {
+			pf = p.idle[len(p.idle)-1]
+			p.idle = p.idle[:len(p.idle)-1]
+			if !pf.fn.Exited() {
+				p.mutex.Unlock()
+				go p.fill()
+				return pf, nil
+			}
+			// the function died while sitting in the pool
+			p.live--
+			go p.stopFunction(pf)
+		}
+		if !p.atCapacity() {
+			break
+		}
+		p.cond.Wait()
+	}
+	p.live++
+	p.mutex.Unlock()
+
+	if pf, err = p.startFunction(); err != nil {
+		p.release()
+	}
+	go p.fill()
+	return
+}
+
+func (p *functionPool) release() {
+	p.mutex.Lock()
+	p.live--
+	p.mutex.Unlock()
+	p.cond.Signal()
+}
+
+// remove takes fn out of the idle list so that it can't be checked out. It
+// returns false if fn isn't idle
+func (p *functionPool) remove(fn *Function) (pf pooledFunction, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i := range p.idle {
+		if p.idle[i].fn == fn {
+			pf = p.idle[i]
+			p.idle = append(p.idle[:i], p.idle[i+1:]...)
+			p.live--
+			p.cond.Signal()
+			return pf, true
+		}
+	}
+	return
+}
+
+// put stops a checked out function and replaces it
+func (p *functionPool) put(pf pooledFunction) {
+	p.stopFunction(pf)
+	p.release()
+	p.fill()
+}
+
+// flush stops all idle functions and starts fresh ones
+func (p *functionPool) flush() {
+	p.mutex.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.live -= len(idle)
+	p.generation++
+	p.mutex.Unlock()
+	p.cond.Broadcast()
+	for _, pf := range idle {
+		p.stopFunction(pf)
+	}
+	p.fill()
+}
+
+func (m *Master) pool(name string) *functionPool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	p, ok := m.pools[name]
+	if !ok {
+		p = newFunctionPool(m, name)
+		m.pools[name] = p
+	}
+	return p
+}
+
+// ConfigureFunctionPool sets the number of functions that are kept warm and the
+// maximum number of functions that can be running at once for a function name.
+// A max of 0 means there is no limit.
+func (m *Master) ConfigureFunctionPool(name string, min, max int) error {
+	if max > 0 && min > max {
+		return errors.Errorf("function %s has a pool min of %d which is larger than the max of %d", name, min, max)
+	}
+	p := m.pool(name)
+	p.mutex.Lock()
+	p.min = min
+	p.max = max
+	p.mutex.Unlock()
+	p.fill()
+	return nil
+}
+
+// stopIdleFunction stops fn if it's idle in its pool and starts a
+// replacement. It returns false if fn isn't idle
+func (m *Master) stopIdleFunction(fn *Function) bool {
+	m.mutex.Lock()
+	p, ok := m.pools[fn.name]
+	m.mutex.Unlock()
+	if !ok {
+		return false
+	}
+	pf, ok := p.remove(fn)
+	if ok {
+		p.stopFunction(pf)
+		p.fill()
+	}
+	return ok
+}
+
+// FlushFunctionPool replaces all idle functions with new ones, used when the
+// function's module has been rebuilt
+func (m *Master) FlushFunctionPool(name string) {
+	m.pool(name).flush()
+}
+
+// CheckoutFunction returns a started function and the gateway it is attached to.
+// The function must be handed back with ReturnFunction
+func (m *Master) CheckoutFunction(name string) (gat *Gateway, fn *Function, err error) {
+	pf, err := m.pool(name).get()
+	return pf.gat, pf.fn, err
+}
+
+// ReturnFunction hands a function back to its pool once a gateway is done with it
+func (m *Master) ReturnFunction(gat *Gateway, fn *Function) {
+	// messages the function sends from now on aren't part of the request's
+	// trace
+	fn.setTraceContext(tracing.SpanContext{})
+	m.pool(fn.name).put(pooledFunction{gat: gat, fn: fn})
+}
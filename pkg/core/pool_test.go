@@ -0,0 +1,92 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"embly/pkg/tester"
+	"embly/pkg/tracing"
+)
+
+func waitForIdle(p *functionPool, count int) bool {
+	for i := 0; i < 100; i++ {
+		p.mutex.Lock()
+		idle := len(p.idle)
+		p.mutex.Unlock()
+		if idle == count {
+			return true
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	return false
+}
+
+func TestFunctionPool(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	t.ErrorContains(m.ConfigureFunctionPool("foo", 3, 2), "larger than the max")
+	t.PanicOnErr(m.ConfigureFunctionPool("foo", 2, 3))
+	p := m.pool("foo")
+	t.Assert().True(waitForIdle(p, 2), "pool should warm up two functions")
+
+	gat, fn, err := m.CheckoutFunction("foo")
+	t.PanicOnErr(err)
+	sending := []byte("it's lunchtime")
+	_, err = gat.Write(sending)
+	t.Assert().NoError(err)
+	buf := make([]byte, len(sending))
+	_, err = gat.Read(buf)
+	t.Assert().NoError(err)
+	t.Assert().Equal(sending, buf)
+
+	// the pool is refilled while the function is checked out
+	t.Assert().True(waitForIdle(p, 2), "pool should be refilled")
+	p.mutex.Lock()
+	t.Assert().Equal(3, p.live)
+	p.mutex.Unlock()
+
+	sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	t.PanicOnErr(err)
+	fn.setTraceContext(sc)
+	m.ReturnFunction(gat, fn)
+	t.Assert().False(fn.traceContext().IsValid(), "returned function should leave the request's trace")
+	if _, ok := m.registry.Load(fn.addr); ok {
+		t.Error("returned function should be removed")
+	}
+	p.mutex.Lock()
+	t.Assert().Equal(2, p.live)
+	p.mutex.Unlock()
+
+	m.FlushFunctionPool("foo")
+	t.Assert().True(waitForIdle(p, 2), "pool should be refilled after a flush")
+}
+
+func TestKillIdleFunction(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	t.PanicOnErr(m.ConfigureFunctionPool("foo", 1, 1))
+	p := m.pool("foo")
+	t.Assert().True(waitForIdle(p, 1), "pool should warm up a function")
+	p.mutex.Lock()
+	killed := p.idle[0]
+	p.mutex.Unlock()
+
+	t.PanicOnErr(m.KillFunction(killed.fn.addr))
+	// the killed function is out of the pool before it's stopped, so it's never
+	// checked out
+	_, fn, err := m.CheckoutFunction("foo")
+	t.PanicOnErr(err)
+	t.Assert().NotEqual(killed.fn, fn)
+	if _, ok := m.registry.Load(killed.gat.ID); ok {
+		t.Error("the killed function's gateway should be removed")
+	}
+}
@@ -20,19 +20,67 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// ErrorCode is set on messages the master sends back to a function when
+// something it sent couldn't be handled. The message data contains a
+// description of the error.
+type ErrorCode int32
+
+const (
+	ErrorCode_NONE ErrorCode = 0
+	// the message was sent to an address that doesn't exist
+	ErrorCode_ADDRESS_NOT_FOUND ErrorCode = 1
+	// the spawn path isn't a valid function, database or kv path
+	ErrorCode_INVALID_SPAWN ErrorCode = 2
+	// the function's allow policy doesn't permit the spawn or message
+	ErrorCode_NOT_ALLOWED ErrorCode = 13
+	// the spawned function couldn't be started
+	ErrorCode_SPAWN_FAILED ErrorCode = 21
+	// the kv store couldn't complete the request
+	ErrorCode_KV_ERROR ErrorCode = 28
+)
+
+var ErrorCode_name = map[int32]string{
+	0:  "NONE",
+	1:  "ADDRESS_NOT_FOUND",
+	2:  "INVALID_SPAWN",
+	13: "NOT_ALLOWED",
+	21: "SPAWN_FAILED",
+	28: "KV_ERROR",
+}
+
+var ErrorCode_value = map[string]int32{
+	"NONE":              0,
+	"ADDRESS_NOT_FOUND": 1,
+	"INVALID_SPAWN":     2,
+	"NOT_ALLOWED":       13,
+	"SPAWN_FAILED":      21,
+	"KV_ERROR":          28,
+}
+
+func (x ErrorCode) String() string {
+	return proto.EnumName(ErrorCode_name, int32(x))
+}
+
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_db39efb7717b7d47, []int{0}
+}
+
 type Message struct {
-	To                   uint64   `protobuf:"varint,1,opt,name=to,proto3" json:"to,omitempty"`
-	From                 uint64   `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
-	Data                 []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
-	Spawn                string   `protobuf:"bytes,4,opt,name=spawn,proto3" json:"spawn,omitempty"`
-	SpawnAddress         uint64   `protobuf:"varint,5,opt,name=spawn_address,json=spawnAddress,proto3" json:"spawn_address,omitempty"`
-	Kill                 bool     `protobuf:"varint,6,opt,name=kill,proto3" json:"kill,omitempty"`
-	Exiting              bool     `protobuf:"varint,7,opt,name=exiting,proto3" json:"exiting,omitempty"`
-	Exit                 int32    `protobuf:"varint,8,opt,name=exit,proto3" json:"exit,omitempty"`
-	YourAddress          uint64   `protobuf:"varint,9,opt,name=your_address,json=yourAddress,proto3" json:"your_address,omitempty"`
-	ParentAddress        uint64   `protobuf:"varint,10,opt,name=parent_address,json=parentAddress,proto3" json:"parent_address,omitempty"`
-	Error                int32    `protobuf:"varint,11,opt,name=error,proto3" json:"error,omitempty"`
-	Startup              *Startup `protobuf:"bytes,12,opt,name=startup,proto3" json:"startup,omitempty"`
+	To            uint64    `protobuf:"varint,1,opt,name=to,proto3" json:"to,omitempty"`
+	From          uint64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Data          []byte    `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Spawn         string    `protobuf:"bytes,4,opt,name=spawn,proto3" json:"spawn,omitempty"`
+	SpawnAddress  uint64    `protobuf:"varint,5,opt,name=spawn_address,json=spawnAddress,proto3" json:"spawn_address,omitempty"`
+	Kill          bool      `protobuf:"varint,6,opt,name=kill,proto3" json:"kill,omitempty"`
+	Exiting       bool      `protobuf:"varint,7,opt,name=exiting,proto3" json:"exiting,omitempty"`
+	Exit          int32     `protobuf:"varint,8,opt,name=exit,proto3" json:"exit,omitempty"`
+	YourAddress   uint64    `protobuf:"varint,9,opt,name=your_address,json=yourAddress,proto3" json:"your_address,omitempty"`
+	ParentAddress uint64    `protobuf:"varint,10,opt,name=parent_address,json=parentAddress,proto3" json:"parent_address,omitempty"`
+	Error         ErrorCode `protobuf:"varint,11,opt,name=error,proto3,enum=comms.ErrorCode" json:"error,omitempty"`
+	Startup       *Startup  `protobuf:"bytes,12,opt,name=startup,proto3" json:"startup,omitempty"`
+	// traceparent is the w3c trace context of the span that sent the message,
+	// it's empty when the message isn't part of a trace
+	Traceparent          string   `protobuf:"bytes,13,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -133,11 +181,11 @@ func (m *Message) GetParentAddress() uint64 {
 	return 0
 }
 
-func (m *Message) GetError() int32 {
+func (m *Message) GetError() ErrorCode {
 	if m != nil {
 		return m.Error
 	}
-	return 0
+	return ErrorCode_NONE
 }
 
 func (m *Message) GetStartup() *Startup {
@@ -147,6 +195,13 @@ func (m *Message) GetStartup() *Startup {
 	return nil
 }
 
+func (m *Message) GetTraceparent() string {
+	if m != nil {
+		return m.Traceparent
+	}
+	return ""
+}
+
 type Startup struct {
 	Module               string   `protobuf:"bytes,1,opt,name=module,proto3" json:"module,omitempty"`
 	Addr                 uint64   `protobuf:"varint,2,opt,name=addr,proto3" json:"addr,omitempty"`
@@ -274,6 +329,7 @@ func (m *DB) GetToken() string {
 }
 
 func init() {
+	proto.RegisterEnum("comms.ErrorCode", ErrorCode_name, ErrorCode_value)
 	proto.RegisterType((*Message)(nil), "comms.Message")
 	proto.RegisterType((*Startup)(nil), "comms.Startup")
 	proto.RegisterType((*DB)(nil), "comms.DB")
@@ -282,27 +338,34 @@ func init() {
 func init() { proto.RegisterFile("comms.proto", fileDescriptor_db39efb7717b7d47) }
 
 var fileDescriptor_db39efb7717b7d47 = []byte{
-	// 343 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x92, 0xd1, 0x6a, 0xc2, 0x30,
-	0x14, 0x86, 0x69, 0x6d, 0xad, 0x3d, 0xad, 0x5e, 0x04, 0x19, 0x81, 0xc1, 0xc8, 0x1c, 0x83, 0x5c,
-	0x79, 0xe1, 0x9e, 0x60, 0xe2, 0xed, 0x6e, 0xb2, 0x07, 0x18, 0xd1, 0x66, 0xd2, 0x69, 0x93, 0x92,
-	0x44, 0x36, 0xdf, 0x70, 0x8f, 0x35, 0x72, 0xd2, 0xca, 0xee, 0xfe, 0xff, 0xeb, 0xcf, 0xf9, 0x93,
-	0xd3, 0x40, 0x75, 0x30, 0x5d, 0xe7, 0xd6, 0xbd, 0x35, 0xde, 0x90, 0x1c, 0xcd, 0xea, 0x37, 0x85,
-	0xe2, 0x4d, 0x39, 0x27, 0x8f, 0x8a, 0x2c, 0x20, 0xf5, 0x86, 0x26, 0x2c, 0xe1, 0x99, 0x48, 0xbd,
-	0x21, 0x04, 0xb2, 0x4f, 0x6b, 0x3a, 0x9a, 0x22, 0x41, 0x1d, 0x58, 0x23, 0xbd, 0xa4, 0x13, 0x96,
-	0xf0, 0x5a, 0xa0, 0x26, 0x4b, 0xc8, 0x5d, 0x2f, 0xbf, 0x35, 0xcd, 0x58, 0xc2, 0x4b, 0x11, 0x0d,
-	0x79, 0x82, 0x39, 0x8a, 0x0f, 0xd9, 0x34, 0x56, 0x39, 0x47, 0x73, 0x1c, 0x53, 0x23, 0x7c, 0x8d,
-	0x2c, 0x8c, 0x3b, 0xb5, 0xe7, 0x33, 0x9d, 0xb2, 0x84, 0xcf, 0x04, 0x6a, 0x42, 0xa1, 0x50, 0x3f,
-	0xad, 0x6f, 0xf5, 0x91, 0x16, 0x88, 0x47, 0x1b, 0xd2, 0x41, 0xd2, 0x19, 0x4b, 0x78, 0x2e, 0x50,
-	0x93, 0x47, 0xa8, 0xaf, 0xe6, 0x62, 0x6f, 0x2d, 0x25, 0xb6, 0x54, 0x81, 0x8d, 0x25, 0xcf, 0xb0,
-	0xe8, 0xa5, 0x55, 0xda, 0xdf, 0x42, 0x80, 0xa1, 0x79, 0xa4, 0x63, 0x6c, 0x09, 0xb9, 0xb2, 0xd6,
-	0x58, 0x5a, 0xe1, 0xf8, 0x68, 0x08, 0x87, 0xc2, 0x79, 0x69, 0xfd, 0xa5, 0xa7, 0x35, 0x4b, 0x78,
-	0xb5, 0x59, 0xac, 0xe3, 0x1a, 0xdf, 0x23, 0x15, 0xe3, 0xe7, 0xd5, 0x17, 0x14, 0x03, 0x23, 0x77,
-	0x30, 0xed, 0x4c, 0x73, 0x39, 0x2b, 0xdc, 0x66, 0x29, 0x06, 0x17, 0x2e, 0x10, 0x8e, 0x30, 0x6e,
-	0x34, 0xe8, 0x90, 0x8d, 0xe7, 0xc0, 0x9d, 0x66, 0x62, 0x70, 0xe4, 0x1e, 0x26, 0xcd, 0xde, 0xd1,
-	0x8c, 0x4d, 0x78, 0xb5, 0x29, 0x87, 0xd2, 0xdd, 0x56, 0x04, 0xba, 0xda, 0x43, 0xba, 0xdb, 0x86,
-	0x71, 0xfe, 0xda, 0x8f, 0x25, 0xa8, 0x03, 0xd3, 0xb2, 0x53, 0x58, 0x51, 0x0a, 0xd4, 0xe4, 0x01,
-	0xe0, 0x60, 0xb4, 0x56, 0x07, 0xdf, 0x1a, 0x8d, 0x35, 0xa5, 0xf8, 0x47, 0xc2, 0xcd, 0xbd, 0x39,
-	0xa9, 0xdb, 0x0f, 0x44, 0xb3, 0x9f, 0xe2, 0x43, 0x79, 0xf9, 0x0b, 0x00, 0x00, 0xff, 0xff, 0xc9,
-	0xde, 0xea, 0xec, 0x37, 0x02, 0x00, 0x00,
+	// 459 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x92, 0x51, 0x8f, 0x93, 0x40,
+	0x14, 0x85, 0x85, 0x96, 0x52, 0x2e, 0x50, 0xd9, 0x89, 0x6b, 0x26, 0xd1, 0x18, 0xac, 0xd1, 0x10,
+	0x1f, 0xf6, 0xa1, 0xfe, 0x02, 0x56, 0xd8, 0xa4, 0xb1, 0x82, 0x99, 0xea, 0xee, 0x23, 0xa1, 0x65,
+	0xdc, 0xd4, 0x2d, 0x0c, 0x19, 0xa6, 0xd1, 0xfd, 0xef, 0x3e, 0x6c, 0xe6, 0x02, 0xcd, 0xbe, 0x9d,
+	0xf3, 0xcd, 0xe9, 0x3d, 0xd3, 0xb9, 0x80, 0xbb, 0x17, 0x75, 0xdd, 0x5d, 0xb5, 0x52, 0x28, 0x41,
+	0x2c, 0x34, 0xcb, 0xff, 0x26, 0xd8, 0xdf, 0x79, 0xd7, 0x95, 0xf7, 0x9c, 0x2c, 0xc0, 0x54, 0x82,
+	0x1a, 0xa1, 0x11, 0x4d, 0x99, 0xa9, 0x04, 0x21, 0x30, 0xfd, 0x2d, 0x45, 0x4d, 0x4d, 0x24, 0xa8,
+	0x35, 0xab, 0x4a, 0x55, 0xd2, 0x49, 0x68, 0x44, 0x1e, 0x43, 0x4d, 0x5e, 0x81, 0xd5, 0xb5, 0xe5,
+	0xdf, 0x86, 0x4e, 0x43, 0x23, 0x72, 0x58, 0x6f, 0xc8, 0x07, 0xf0, 0x51, 0x14, 0x65, 0x55, 0x49,
+	0xde, 0x75, 0xd4, 0xc2, 0x31, 0x1e, 0xc2, 0xb8, 0x67, 0x7a, 0xdc, 0xc3, 0xe1, 0x78, 0xa4, 0xb3,
+	0xd0, 0x88, 0xe6, 0x0c, 0x35, 0xa1, 0x60, 0xf3, 0x7f, 0x07, 0x75, 0x68, 0xee, 0xa9, 0x8d, 0x78,
+	0xb4, 0x3a, 0xad, 0x25, 0x9d, 0x87, 0x46, 0x64, 0x31, 0xd4, 0xe4, 0x3d, 0x78, 0x8f, 0xe2, 0x24,
+	0xcf, 0x2d, 0x0e, 0xb6, 0xb8, 0x9a, 0x8d, 0x25, 0x1f, 0x61, 0xd1, 0x96, 0x92, 0x37, 0xea, 0x1c,
+	0x02, 0x0c, 0xf9, 0x3d, 0x1d, 0x63, 0x9f, 0xc0, 0xe2, 0x52, 0x0a, 0x49, 0xdd, 0xd0, 0x88, 0x16,
+	0xab, 0xe0, 0xaa, 0x7f, 0xae, 0x54, 0xb3, 0xaf, 0xa2, 0xe2, 0xac, 0x3f, 0x26, 0x11, 0xd8, 0x9d,
+	0x2a, 0xa5, 0x3a, 0xb5, 0xd4, 0x0b, 0x8d, 0xc8, 0x5d, 0x2d, 0x86, 0xe4, 0xb6, 0xa7, 0x6c, 0x3c,
+	0x26, 0x21, 0xb8, 0x4a, 0x96, 0x7b, 0xde, 0xf7, 0x50, 0x1f, 0x9f, 0xe7, 0x39, 0x5a, 0xfe, 0x01,
+	0x7b, 0xf8, 0x15, 0x79, 0x0d, 0xb3, 0x5a, 0x54, 0xa7, 0x23, 0xc7, 0x0d, 0x38, 0x6c, 0x70, 0xfa,
+	0x4f, 0xeb, 0x6b, 0x8f, 0x5b, 0xd0, 0x5a, 0x67, 0x87, 0x99, 0x13, 0xa4, 0x83, 0x23, 0x6f, 0x60,
+	0x52, 0xed, 0x3a, 0x3a, 0x0d, 0x27, 0x91, 0xbb, 0x72, 0x86, 0x6b, 0x25, 0xd7, 0x4c, 0xd3, 0xe5,
+	0x0e, 0xcc, 0xe4, 0x5a, 0x8f, 0x53, 0x8f, 0xed, 0x58, 0x82, 0x5a, 0xb3, 0xa6, 0xac, 0x39, 0x56,
+	0x38, 0x0c, 0x35, 0x79, 0x07, 0xb0, 0x17, 0x4d, 0xc3, 0xf7, 0xea, 0x20, 0x1a, 0xac, 0x71, 0xd8,
+	0x33, 0xa2, 0x97, 0xae, 0xc4, 0x03, 0x3f, 0x2f, 0x1d, 0xcd, 0xe7, 0x16, 0x9c, 0xf3, 0x7b, 0x91,
+	0x39, 0x4c, 0xb3, 0x3c, 0x4b, 0x83, 0x17, 0xe4, 0x12, 0x2e, 0xe2, 0x24, 0x61, 0xe9, 0x76, 0x5b,
+	0x64, 0xf9, 0xcf, 0xe2, 0x26, 0xff, 0x95, 0x25, 0x81, 0x41, 0x2e, 0xc0, 0x5f, 0x67, 0xb7, 0xf1,
+	0x66, 0x9d, 0x14, 0xdb, 0x1f, 0xf1, 0x5d, 0x16, 0x98, 0xe4, 0x25, 0xb8, 0x3a, 0x11, 0x6f, 0x36,
+	0xf9, 0x5d, 0x9a, 0x04, 0x3e, 0x09, 0xc0, 0xc3, 0xb3, 0xe2, 0x26, 0x5e, 0x6f, 0xd2, 0x24, 0xb8,
+	0x24, 0x1e, 0xcc, 0xbf, 0xdd, 0x16, 0x29, 0x63, 0x39, 0x0b, 0xde, 0xee, 0x66, 0xf8, 0x39, 0x7f,
+	0x79, 0x1a, 0x00, 0xe4, 0x3f, 0xbc, 0x74, 0xdd, 0x02, 0x00, 0x00,
 }
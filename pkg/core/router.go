@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// routeParamsKey is the request context key for parameters extracted from the
+// request path
+type routeParamsKey struct{}
+
+// routeParams returns the path parameters that the router matched for a request
+func routeParams(r *http.Request) map[string]string {
+	params, _ := r.Context().Value(routeParamsKey{}).(map[string]string)
+	return params
+}
+
+// route is a single gateway route. Paths are matched like http.ServeMux, a
+// path that ends in a slash matches everything below it, a path without one
+// only matches itself, and segments that start with a colon match any single
+// segment and are passed to the handler as parameters
+type route struct {
+	methods  []string
+	host     string
+	segments []string
+	prefix   bool
+	handler  http.Handler
+}
+
+// router routes requests by method, host and path
+type router struct {
+	routes []*route
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// cleanPath returns the canonical path for p, like http.ServeMux it removes .
+// and .. elements and repeated slashes but keeps a trailing slash
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+func redirectToPath(w http.ResponseWriter, r *http.Request, p string) {
+	u := url.URL{Path: p, RawQuery: r.URL.RawQuery}
+	http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+}
+
+// Handle registers a handler for a path. Methods and host are optional, a
+// host that starts with "*." matches every subdomain
+func (rt *router) Handle(methods []string, host string, path string, handler http.Handler) {
+	upper := make([]string, len(methods))
+	for i, m := range methods {
+		upper[i] = strings.ToUpper(m)
+	}
+	segments := splitPath(path)
+	if path == "/" {
+		segments = nil
+	}
+	rt.routes = append(rt.routes, &route{
+		methods:  upper,
+		host:     strings.ToLower(host),
+		segments: segments,
+		prefix:   strings.HasSuffix(path, "/"),
+		handler:  handler,
+	})
+}
+
+func (rte *route) matchHost(host string) bool {
+	if rte.host == "" {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if strings.HasPrefix(rte.host, "*.") {
+		return strings.HasSuffix(host, rte.host[1:])
+	}
+	return host == rte.host
+}
+
+func (rte *route) matchPath(segments []string, trailingSlash bool) (params map[string]string, ok bool) {
+	pattern := rte.segments
+	if rte.prefix {
+		// "/" and "/foo/" match everything below them, "/foo" doesn't match
+		// and is redirected instead
+		if len(segments) < len(pattern) || len(segments) == len(pattern) && !trailingSlash {
+			return nil, false
+		}
+	} else if len(segments) != len(pattern) || trailingSlash {
+		return nil, false
+	}
+	for i, p := range pattern {
+		if strings.HasPrefix(p, ":") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[p[1:]] = segments[i]
+		} else if p != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (rte *route) matchMethod(method string) bool {
+	if len(rte.methods) == 0 {
+		return true
+	}
+	for _, m := range rte.methods {
+		if m == method || m == http.MethodGet && method == http.MethodHead {
+			return true
+		}
+	}
+	return false
+}
+
+// score ranks matching routes so that the most specific one is used. Routes
+// with a host beat routes without one, then exact paths beat prefixes, then
+// longer paths and paths with fewer parameters win
+func (rte *route) score() (score int) {
+	if rte.host != "" {
+		score += 1 << 20
+	}
+	if !rte.prefix {
+		score += 1 << 19
+	}
+	for _, s := range rte.segments {
+		score += 1 << 10
+		if !strings.HasPrefix(s, ":") {
+			score++
+		}
+	}
+	return
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// like http.ServeMux, requests for unclean paths are redirected
+	if r.Method != http.MethodConnect {
+		if p := cleanPath(r.URL.Path); p != r.URL.Path {
+			redirectToPath(w, r, p)
+			return
+		}
+	}
+	segments := splitPath(r.URL.Path)
+	trailingSlash := strings.HasSuffix(r.URL.Path, "/")
+	var best *route
+	var bestParams map[string]string
+	allowed := []string{}
+	redirect := false
+	for _, rte := range rt.routes {
+		if !rte.matchHost(r.Host) {
+			continue
+		}
+		params, ok := rte.matchPath(segments, trailingSlash)
+		if !ok {
+			// "/static" is redirected to a "/static/" route unless another
+			// route matches it
+			if _, root := rte.matchPath(segments, true); root && rte.prefix && !trailingSlash {
+				redirect = true
+			}
+			continue
+		}
+		if !rte.matchMethod(r.Method) {
+			for _, m := range rte.methods {
+				if !contains(allowed, m) {
+					allowed = append(allowed, m)
+				}
+			}
+			continue
+		}
+		if best == nil || rte.score() > best.score() {
+			best, bestParams = rte, params
+		}
+	}
+	if best == nil {
+		if redirect {
+			redirectToPath(w, r, r.URL.Path+"/")
+			return
+		}
+		if len(allowed) > 0 {
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if bestParams != nil {
+		r = r.WithContext(context.WithValue(r.Context(), routeParamsKey{}, bestParams))
+	}
+	best.handler.ServeHTTP(w, r)
+}
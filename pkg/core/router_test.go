@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"embly/pkg/tester"
+)
+
+func namedHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+		for _, k := range []string{"id", "post"} {
+			if v, ok := routeParams(r)[k]; ok {
+				fmt.Fprintf(w, " %s=%s", k, v)
+			}
+		}
+	})
+}
+
+func TestRouter(te *testing.T) {
+	t := tester.New(te)
+	rt := &router{}
+	rt.Handle(nil, "", "/", namedHandler("root"))
+	rt.Handle(nil, "", "/static/", namedHandler("static"))
+	rt.Handle([]string{"get"}, "", "/users/:id", namedHandler("get user"))
+	rt.Handle([]string{"PUT", "DELETE"}, "", "/users/:id", namedHandler("change user"))
+	rt.Handle(nil, "", "/users/me", namedHandler("me"))
+	rt.Handle(nil, "", "/users/:id/posts/:post", namedHandler("post"))
+	rt.Handle(nil, "api.example.com", "/", namedHandler("api"))
+	rt.Handle(nil, "*.example.org", "/", namedHandler("subdomain"))
+
+	for _, tc := range []struct {
+		method, url, body string
+		status            int
+	}{
+		{"GET", "http://localhost/", "root", 200},
+		{"GET", "http://localhost/anything/else", "root", 200},
+		{"GET", "http://localhost/static/css/main.css", "static", 200},
+		{"GET", "http://localhost/users/10", "get user id=10", 200},
+		{"HEAD", "http://localhost/users/10", "", 200},
+		{"DELETE", "http://localhost/users/10", "change user id=10", 200},
+		{"GET", "http://localhost/users/me", "me", 200},
+		{"GET", "http://localhost/users/10/posts/3", "post id=10 post=3", 200},
+		{"GET", "http://api.example.com:8080/users/10", "api", 200},
+		{"GET", "http://blog.example.org/", "subdomain", 200},
+	} {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(tc.method, tc.url, nil))
+		t.Assert().Equal(tc.status, w.Code, tc.url)
+		if tc.method != "HEAD" {
+			t.Assert().Equal(tc.body, w.Body.String(), tc.url)
+		}
+	}
+
+	rt = &router{}
+	rt.Handle([]string{"GET"}, "", "/users/:id", namedHandler("get user"))
+	rt.Handle([]string{"PUT", "GET"}, "", "/users/:id", namedHandler("put user"))
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("POST", "/users/1", nil))
+	t.Assert().Equal(http.StatusMethodNotAllowed, w.Code)
+	t.Assert().Equal("GET, PUT", w.Header().Get("Allow"))
+
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	t.Assert().Equal(http.StatusNotFound, w.Code)
+}
+
+func TestRouterServeMuxBehaviour(te *testing.T) {
+	t := tester.New(te)
+	rt := &router{}
+	rt.Handle(nil, "", "/static/", namedHandler("static"))
+	rt.Handle(nil, "", "/files/", namedHandler("files"))
+	rt.Handle(nil, "", "/files", namedHandler("files root"))
+	rt.Handle(nil, "", "/users/me", namedHandler("me"))
+
+	for _, tc := range []struct {
+		url, location, body string
+		status              int
+	}{
+		// subtree roots are redirected to their trailing slash
+		{"/static", "/static/", "", http.StatusMovedPermanently},
+		{"/static?v=1", "/static/?v=1", "", http.StatusMovedPermanently},
+		// unless there's a route for them
+		{"/files", "", "files root", 200},
+		// paths are cleaned before they're matched
+		{"//a/../static/x", "/static/x", "", http.StatusMovedPermanently},
+		{"/static/./css/", "/static/css/", "", http.StatusMovedPermanently},
+		// exact routes don't match a trailing slash
+		{"/users/me/", "", "404 page not found\n", http.StatusNotFound},
+		{"/users/me", "", "me", 200},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "http://localhost/", nil)
+		r.URL.Path, r.URL.RawQuery = tc.url, ""
+		if i := strings.Index(tc.url, "?"); i >= 0 {
+			r.URL.Path, r.URL.RawQuery = tc.url[:i], tc.url[i+1:]
+		}
+		rt.ServeHTTP(w, r)
+		t.Assert().Equal(tc.status, w.Code, tc.url)
+		t.Assert().Equal(tc.location, w.Header().Get("Location"), tc.url)
+		if tc.body != "" {
+			t.Assert().Equal(tc.body, w.Body.String(), tc.url)
+		}
+	}
+}
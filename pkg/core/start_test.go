@@ -0,0 +1,229 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"embly/pkg/config"
+	"embly/pkg/core/httpproto"
+	"embly/pkg/tester"
+
+	"github.com/mitchellh/cli"
+	"github.com/pkg/errors"
+)
+
+func TestHTTPRequestBodyStreaming(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	server := httptest.NewServer(http.HandlerFunc(m.functionHandlerFunc("foo", 0)))
+	defer server.Close()
+
+	// the mock wrapper echoes every message, so the request headers, body and
+	// eof message come back as the response
+	body := bytes.Repeat([]byte("it's lunchtime "), 100000)
+	resp, err := http.Post(server.URL, "text/plain", bytes.NewReader(body))
+	t.PanicOnErr(err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	t.PanicOnErr(err)
+	t.Assert().Equal(http.StatusOK, resp.StatusCode)
+	t.Assert().Equal(body, b)
+
+	resp, err = http.Get(server.URL)
+	t.PanicOnErr(err)
+	defer resp.Body.Close()
+	b, err = ioutil.ReadAll(resp.Body)
+	t.PanicOnErr(err)
+	t.Assert().Empty(b)
+}
+
+func TestStreamRequestBodyTrailers(te *testing.T) {
+	t := tester.New(te)
+	var buf bytes.Buffer
+	rw := httpproto.ReadWriter{ReadWriter: &buf}
+	r := httptest.NewRequest("POST", "/", strings.NewReader("body"))
+	r.Trailer = http.Header{"X-Checksum": {"abc"}}
+	t.PanicOnErr(streamRequestBody(&rw, r))
+
+	msg, err := rw.Next()
+	t.PanicOnErr(err)
+	t.Assert().Equal([]byte("body"), msg.Body)
+	msg, err = rw.Next()
+	t.PanicOnErr(err)
+	t.Assert().True(msg.Eof)
+	t.Assert().Equal([]string{"abc"}, msg.Trailers["X-Checksum"].Header)
+}
+
+func TestHTTPResponseHeadersAndTrailers(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	f := startFakeFunction(t, m, "foo")
+	defer f.conn.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Assert().NoError(m.serveFunction(w, r, f.gat))
+	}))
+	defer server.Close()
+
+	firstChunkRead := make(chan struct{})
+	go func() {
+		var req, eof httpproto.Http
+		f.next(&req)
+		f.next(&eof)
+		f.send(&httpproto.Http{Headers: map[string]*httpproto.HeaderList{
+			"X-First": {Header: []string{"1"}},
+		}})
+		f.send(&httpproto.Http{Status: http.StatusCreated, Headers: map[string]*httpproto.HeaderList{
+			"X-Second": {Header: []string{"2"}},
+		}})
+		f.send(&httpproto.Http{Body: []byte("data: one\n\n")})
+		// the first chunk has to be flushed for the client to read it
+		<-firstChunkRead
+		f.send(&httpproto.Http{Body: []byte("data: two\n\n"), Headers: map[string]*httpproto.HeaderList{
+			"X-Late": {Header: []string{"ignored"}},
+		}})
+		f.send(&httpproto.Http{Eof: true, Trailers: map[string]*httpproto.HeaderList{
+			"X-Checksum": {Header: []string{"abc"}},
+		}})
+	}()
+
+	resp, err := http.Get(server.URL)
+	t.PanicOnErr(err)
+	defer resp.Body.Close()
+	t.Assert().Equal(http.StatusCreated, resp.StatusCode)
+	t.Assert().Equal("1", resp.Header.Get("X-First"))
+	t.Assert().Equal("2", resp.Header.Get("X-Second"))
+	t.Assert().Equal("", resp.Header.Get("X-Late"))
+
+	buf := make([]byte, len("data: one\n\n"))
+	_, err = io.ReadFull(resp.Body, buf)
+	t.PanicOnErr(err)
+	t.Assert().Equal("data: one\n\n", string(buf))
+	close(firstChunkRead)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	t.PanicOnErr(err)
+	t.Assert().Equal("data: two\n\n", string(b))
+	t.Assert().Equal("abc", resp.Trailer.Get("X-Checksum"))
+}
+
+func TestHTTPTimeout(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	f := startFakeFunction(t, m, "foo")
+	defer f.conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	served := make(chan error)
+	go func() { served <- m.serveFunction(w, r, f.gat) }()
+
+	// the function reads the request but never responds
+	var req, eof httpproto.Http
+	f.next(&req)
+	f.next(&eof)
+	msg, err := NextMessage(f.conn)
+	t.PanicOnErr(err)
+	t.Assert().True(msg.Kill)
+	t.Assert().Equal(errFunctionTimeout, <-served)
+}
+
+// unconnectedFunction starts a function attached to a new gateway that can't
+// connect because the master isn't listening
+func unconnectedFunction(t tester.Tester, m *Master, dir string) (*Gateway, *Function) {
+	m.sockAddr = filepath.Join(dir, "embly.sock")
+	m.functions["foo"] = ""
+	gat := m.NewGateway()
+	fn, err := m.NewFunction("foo", gat.ID, nil, nil)
+	t.PanicOnErr(err)
+	gat.AttachFn(fn)
+	t.PanicOnErr(fn.Start())
+	return gat, fn
+}
+
+func TestFunctionExitsBeforeConnecting(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+
+	gat, fn := unconnectedFunction(t, NewMaster(), dir)
+	t.ErrorContains(gat.master.serveFunction(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), gat),
+		"exited before it connected")
+	t.Assert().True(fn.Exited())
+
+	// a request that times out before its function connects stops the
+	// function instead of waiting for it
+	gat, fn = unconnectedFunction(t, NewMaster(), dir)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	t.Assert().Equal(errFunctionTimeout, gat.master.serveFunction(httptest.NewRecorder(), r, gat))
+	t.Assert().True(fn.Exited())
+	t.Assert().True(time.Since(start) < 400*time.Millisecond, "the function should be stopped")
+}
+
+func TestGatewayTLSConfig(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	g := config.Gateway{
+		Type: "http",
+		Port: 8443,
+		TLS:  &config.GatewayTLS{},
+		Routes: []config.GatewayRoute{
+			{Path: "/", Host: "App.test"},
+			{Path: "/api/", Host: "*.app.test"},
+			{Path: "/other/"},
+		},
+	}
+	_, err := m.gatewayTLSConfig(g)
+	t.ErrorContains(err, "only available in dev mode")
+
+	t.Assert().Equal(
+		[]string{"localhost", "127.0.0.1", "::1", "app.test", "*.app.test"},
+		gatewayHosts("0.0.0.0", g))
+}
+
+func TestUntilSignal(te *testing.T) {
+	t := tester.New(te)
+	ui := cli.NewMockUi()
+	signals := make(chan os.Signal, 1)
+	t.ErrorContains(untilSignal(ui, signals, func() error {
+		return errors.New("failed")
+	}), "failed")
+
+	// a signal stops the wait for a step that is still running
+	block := make(chan struct{})
+	defer close(block)
+	signals <- syscall.SIGINT
+	err := untilSignal(ui, signals, func() error {
+		<-block
+		return nil
+	})
+	t.Assert().Equal(errInterrupted, err)
+	t.Assert().Contains(ui.OutputWriter.String(), "Received interrupt, shutting down")
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	comms_proto "embly/pkg/core/proto"
+	"embly/pkg/tracing"
+
+	"github.com/mitchellh/cli"
+	"github.com/pkg/errors"
+)
+
+// traceService is the service name spans are exported with
+const traceService = "embly"
+
+// newTracer creates a tracer for the exporters in the start config, there is no
+// tracer if none are configured
+func newTracer(startConfig StartConfig, ui cli.Ui) (*tracing.Tracer, error) {
+	var exporters []tracing.Exporter
+	if startConfig.TraceEndpoint != "" {
+		u, err := url.Parse(startConfig.TraceEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.Errorf(`trace endpoint "%s" must be an http or https url`, startConfig.TraceEndpoint)
+		}
+		exporters = append(exporters, tracing.NewOTLPExporter(startConfig.TraceEndpoint, traceService))
+	}
+	if startConfig.TraceFile != "" {
+		fe, err := tracing.NewFileExporter(startConfig.TraceFile)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, fe)
+	}
+	if len(exporters) == 0 {
+		return nil, nil
+	}
+	return tracing.NewTracer(func(err error) { ui.Error(err.Error()) }, exporters...), nil
+}
+
+// traceContext is the span context of the request a function is handling.
+// Messages the function sends are part of its trace, it's empty when the
+// function isn't handling a traced request
+func (fn *Function) traceContext() tracing.SpanContext {
+	fn.traceMutex.Lock()
+	defer fn.traceMutex.Unlock()
+	return fn.trace
+}
+
+func (fn *Function) setTraceContext(sc tracing.SpanContext) {
+	fn.traceMutex.Lock()
+	defer fn.traceMutex.Unlock()
+	fn.trace = sc
+}
+
+// startChildSpan starts a span that continues a trace, nothing is recorded if
+// parent isn't part of a trace
+func (m *Master) startChildSpan(name string, parent tracing.SpanContext, kind tracing.SpanKind) *tracing.Span {
+	if !parent.IsValid() {
+		return nil
+	}
+	return m.tracer.Start(name, parent, kind)
+}
+
+// messageTraceContext is the span context of a message sent by a function. A
+// traceparent set by the function is used before the function's own trace
+func (m *Master) messageTraceContext(fn *Function, msg comms_proto.Message) tracing.SpanContext {
+	if m.tracer == nil {
+		return tracing.SpanContext{}
+	}
+	if sc, err := tracing.ParseTraceparent(msg.Traceparent); err == nil {
+		return sc
+	}
+	return fn.traceContext()
+}
+
+// receiverName describes where a message is going
+func receiverName(fog funcOrGateway) string {
+	switch r := fog.(type) {
+	case *Function:
+		return r.name
+	case *Gateway:
+		return "gateway"
+	case *KV:
+		return "kv/" + r.namespace + "/" + r.path
+	case *Vinyl:
+		return "vinyl/" + r.database
+	}
+	return "unknown"
+}
+
+// traceMessage records a span for a message routed between a function and
+// receiver. The message carries the span's context so the receiver can
+// continue the trace
+func (m *Master) traceMessage(parent tracing.SpanContext, msg *comms_proto.Message, receiver funcOrGateway) *tracing.Span {
+	span := m.startChildSpan("message "+receiverName(receiver), parent, tracing.SpanKindInternal)
+	if span == nil {
+		return nil
+	}
+	span.SetAttribute("embly.from", strconv.FormatUint(msg.From, 10))
+	span.SetAttribute("embly.to", strconv.FormatUint(msg.To, 10))
+	span.SetAttribute("embly.bytes", len(msg.Data))
+	msg.Traceparent = span.Context().Traceparent()
+	return span
+}
+
+// traceSpawn records a span from when a function's process was started until
+// it connects. Nothing is recorded if it has already connected or parent is
+// nil
+func (m *Master) traceSpawn(fn *Function, parent *tracing.Span) {
+	if parent == nil {
+		return
+	}
+	select {
+	case <-fn.connected:
+		return
+	default:
+	}
+	span := m.tracer.StartAt("spawn "+fn.name, parent.Context(), tracing.SpanKindInternal, fn.started)
+	span.SetAttribute("embly.function", fn.name)
+	go func() {
+		select {
+		case <-fn.connected:
+		case <-fn.done:
+			span.SetError(errors.New("function exited before connecting"))
+		}
+		span.End()
+	}()
+}
+
+// traceFunction starts a span for a function's work in a trace, messages the
+// function sends are part of the span
+func (m *Master) traceFunction(parent tracing.SpanContext, fn *Function) *tracing.Span {
+	span := m.startChildSpan("function "+fn.name, parent, tracing.SpanKindInternal)
+	if span == nil {
+		return nil
+	}
+	span.SetAttribute("embly.function", fn.name)
+	span.SetAttribute("embly.addr", strconv.FormatUint(fn.addr, 10))
+	fn.setTraceContext(span.Context())
+	return span
+}
+
+// traceCheckout starts a span for a function that was checked out to handle a
+// request, the span must be ended once the function is returned. If the
+// function is still starting a spawn span is recorded as well
+func (m *Master) traceCheckout(parent tracing.SpanContext, fn *Function) *tracing.Span {
+	span := m.traceFunction(parent, fn)
+	m.traceSpawn(fn, span)
+	return span
+}
+
+// withTraceparent returns a copy of r with a traceparent header for span so
+// that the function handling it can see the trace, r is returned if there is
+// no span
+func withTraceparent(r *http.Request, span *tracing.Span) *http.Request {
+	if span == nil {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set("traceparent", span.Context().Traceparent())
+	return r
+}
+
+// traceHandler records a server span for each request to a route. The span
+// continues the trace in the request's traceparent header, or starts a new one
+func (m *Master) traceHandler(route string, h http.Handler) http.Handler {
+	if m.tracer == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, _ := tracing.ParseTraceparent(r.Header.Get("traceparent"))
+		span := m.tracer.Start(r.Method+" "+route, parent, tracing.SpanKindServer)
+		defer span.End()
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.host", r.Host)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", r.URL.RequestURI())
+		span.SetAttribute("http.client_ip", clientIP(r))
+
+		logger := makeLogger(w)
+		h.ServeHTTP(logger, r.WithContext(tracing.ContextWithSpanContext(r.Context(), span.Context())))
+		status := logger.Status()
+		span.SetAttribute("http.status_code", status)
+		if status >= 500 {
+			span.SetError(errors.Errorf("responded with %d", status))
+		}
+	})
+}
+
+// traceTCPConn records a server span for a connection to a tcp gateway
+func (m *Master) traceTCPConn(conn net.Conn) *tracing.Span {
+	span := m.tracer.Start("tcp "+conn.LocalAddr().String(), tracing.SpanContext{}, tracing.SpanKindServer)
+	span.SetAttribute("net.peer.addr", conn.RemoteAddr().String())
+	return span
+}
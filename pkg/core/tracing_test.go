@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"embly/pkg/kv"
+	"embly/pkg/tester"
+	"embly/pkg/tracing"
+)
+
+type memoryExporter struct {
+	mutex sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (me *memoryExporter) Export(spans []tracing.SpanData) error {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.spans = append(me.spans, spans...)
+	return nil
+}
+
+func (me *memoryExporter) Close() error { return nil }
+
+func (me *memoryExporter) named(name string) (span tracing.SpanData, ok bool) {
+	for _, s := range me.spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return
+}
+
+func TestTracing(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	me := &memoryExporter{}
+	m.tracer = tracing.NewTracer(nil, me)
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	incoming := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	var functionSpan *tracing.Span
+	handler := m.traceHandler("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := startFakeFunction(t, m, "foo")
+		defer f.conn.Close()
+		functionSpan = m.traceCheckout(tracing.SpanContextFromContext(r.Context()), f.fn)
+		defer functionSpan.End()
+		r = withTraceparent(r, functionSpan)
+		t.Assert().Equal(functionSpan.Context().Traceparent(), r.Header.Get("traceparent"))
+
+		// messages from the gateway carry the function's trace
+		_, err := f.gat.Write([]byte("hi"))
+		t.PanicOnErr(err)
+		msg, err := NextMessage(f.conn)
+		t.PanicOnErr(err)
+		t.Assert().Equal(functionSpan.Context().Traceparent(), msg.Traceparent)
+
+		// kv requests from the function are part of the trace
+		b, _ := kv.WriteKeyAndValue([]byte("key"), []byte("value"))
+		kvRequest(t, f.conn, f.fn.addr, "embly/kv/set", b)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	r := httptest.NewRequest(http.MethodPut, "/users/1", nil)
+	r.Header.Set("traceparent", incoming)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	t.PanicOnErr(m.tracer.Shutdown(context.Background()))
+
+	server, ok := me.named("PUT /users/:id")
+	t.Assert().True(ok)
+	t.Assert().Equal("4bf92f3577b34da6a3ce929d0e0e4736", server.Context.TraceID.String())
+	t.Assert().Equal("00f067aa0ba902b7", server.Parent.String())
+	t.Assert().Contains(server.Attributes, tracing.Attribute{Key: "http.status_code", Value: http.StatusCreated})
+
+	function, ok := me.named("function foo")
+	t.Assert().True(ok)
+	t.Assert().Equal(server.Context.SpanID, function.Parent)
+
+	message, ok := me.named("message kv/default/set")
+	t.Assert().True(ok)
+	t.Assert().Equal(function.Context.SpanID, message.Parent)
+
+	kvSpan, ok := me.named("kv set")
+	t.Assert().True(ok)
+	t.Assert().Equal(message.Context.SpanID, kvSpan.Parent)
+	t.Assert().Equal(server.Context.TraceID, kvSpan.Context.TraceID)
+	t.Assert().Equal(tracing.SpanKindClient, kvSpan.Kind)
+}
+
+func TestTracingDisabled(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	t.Assert().Equal(r, withTraceparent(r, m.traceFunction(tracing.SpanContext{}, &Function{})))
+
+	_, err := newTracer(StartConfig{TraceEndpoint: "localhost:4318"}, nil)
+	t.ErrorContains(err, "must be an http or https url")
+	tracer, err := newTracer(StartConfig{}, nil)
+	t.PanicOnErr(err)
+	t.Assert().Nil(tracer)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	comms_proto "embly/pkg/core/proto"
+	"embly/pkg/tracing"
 
 	vinyl "github.com/embly/vinyl/vinyl-go"
 	"github.com/embly/vinyl/vinyl-go/transport"
@@ -18,6 +19,7 @@ import (
 type Vinyl struct {
 	master   *Master
 	id       uint64
+	owner    uint64
 	database string
 	conn     net.Conn
 
@@ -27,13 +29,27 @@ type Vinyl struct {
 
 func (v *Vinyl) sendDBRequest(msg comms_proto.Message) (resp *transport.Response, err error) {
 	t := time.Now()
-	db := v.master.databases[v.database]
+	db, ok := v.master.databases[v.database]
+	if !ok {
+		err = errors.Errorf(`database "%s" doesn't exist`, v.database)
+		return
+	}
 	request := transport.Request{}
 
 	if err = proto.Unmarshal(msg.Data, &request); err != nil {
 		return
 	}
+	parent, _ := tracing.ParseTraceparent(msg.Traceparent)
+	span := v.master.startChildSpan("vinyl "+v.database, parent, tracing.SpanKindClient)
+	span.SetAttribute("db.name", v.database)
+	span.SetAttribute("db.statement", vinyl.RequestDescription(&request))
 	resp, err = db.DB.SendRequest(request)
+	if err == nil && resp.Error != "" {
+		span.SetError(errors.New(resp.Error))
+	}
+	span.SetError(err)
+	span.End()
+	v.master.metrics.vinylDuration.Observe(time.Since(t).Seconds(), v.database)
 	v.master.ui.Output(
 		fmt.Sprintf("Vinyl: %s (%s)",
 			vinyl.RequestDescription(&request), time.Now().Sub(t)))
@@ -69,6 +85,9 @@ func (master *Master) spawnVinyl(msg comms_proto.Message, conn net.Conn) (err er
 		return errors.New("missing database path")
 	}
 	path := parts[3]
+	if _, ok := master.databases[database]; !ok {
+		return errors.Errorf(`database "%s" doesn't exist`, database)
+	}
 	if path == "connect" {
 		db := master.databases[database]
 		if err := WriteMessage(
@@ -91,6 +110,7 @@ func (master *Master) spawnVinyl(msg comms_proto.Message, conn net.Conn) (err er
 	v := &Vinyl{
 		master:   master,
 		id:       msg.SpawnAddress,
+		owner:    msg.From,
 		database: database,
 		conn:     conn,
 		path:     path,
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"net/http"
+	"time"
+
+	"embly/pkg/core/httpproto"
+	protoutil "embly/pkg/proto-util"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketCloseTimeout is how long a client has to reply to a close frame sent
+// by a function before the connection is dropped
+var websocketCloseTimeout = time.Second
+
+var websocketUpgrader = websocket.Upgrader{}
+
+// headers that are set by the upgrader and can't be passed in by a function
+var websocketHandshakeHeaders = map[string]bool{
+	"Upgrade":                  true,
+	"Connection":               true,
+	"Sec-Websocket-Accept":     true,
+	"Sec-Websocket-Extensions": true,
+}
+
+// bridgeWebSocket completes a websocket handshake that a function accepted with
+// the headers it sent and passes frames between the client and the function
+// until either side closes the connection
+func (master *Master) bridgeWebSocket(w http.ResponseWriter, r *http.Request, gat *Gateway, accept http.Header) error {
+	header := http.Header{}
+	for k, values := range accept {
+		if websocketHandshakeHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		header[k] = values
+	}
+	ws, err := websocketUpgrader.Upgrade(w, r, header)
+	if err != nil {
+		// the upgrader has already replied to the client with an error
+		return nil
+	}
+	defer ws.Close()
+
+	functionDone := make(chan struct{})
+	go func() {
+		defer close(functionDone)
+		for {
+			var frame httpproto.Frame
+			if err := protoutil.NextMessage(gat, &frame); err != nil {
+				// the function exited without closing the connection
+				ws.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				ws.SetReadDeadline(time.Now().Add(websocketCloseTimeout))
+				return
+			}
+			switch frame.Type {
+			case httpproto.Frame_TEXT:
+				err = ws.WriteMessage(websocket.TextMessage, frame.Data)
+			case httpproto.Frame_BINARY:
+				err = ws.WriteMessage(websocket.BinaryMessage, frame.Data)
+			case httpproto.Frame_CLOSE:
+				code := int(frame.CloseCode)
+				if code == 0 {
+					code = websocket.CloseNormalClosure
+				}
+				ws.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(code, string(frame.Data)))
+				ws.SetReadDeadline(time.Now().Add(websocketCloseTimeout))
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		messageType, data, err := ws.ReadMessage()
+		if err != nil {
+			frame := httpproto.Frame{
+				Type:      httpproto.Frame_CLOSE,
+				CloseCode: websocket.CloseAbnormalClosure,
+			}
+			if closeErr, ok := err.(*websocket.CloseError); ok {
+				frame.CloseCode = int32(closeErr.Code)
+				frame.Data = []byte(closeErr.Text)
+			}
+			protoutil.WriteMessage(gat, &frame)
+			break
+		}
+		frame := httpproto.Frame{Type: httpproto.Frame_TEXT, Data: data}
+		if messageType == websocket.BinaryMessage {
+			frame.Type = httpproto.Frame_BINARY
+		}
+		if err := protoutil.WriteMessage(gat, &frame); err != nil {
+			break
+		}
+	}
+
+	// unblock the function reader if the function is still running
+	gat.Close()
+	<-functionDone
+	return nil
+}
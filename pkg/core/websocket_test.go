@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"embly/pkg/core/httpproto"
+	"embly/pkg/tester"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebSocket(te *testing.T) {
+	t := tester.New(te)
+	m := NewMaster()
+	go m.Start()
+	defer m.Shutdown(context.Background())
+	m.functions["foo"] = ""
+
+	f := startFakeFunction(t, m, "foo")
+	defer f.conn.Close()
+	gat := f.gat
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Assert().NoError(m.serveFunction(w, r, gat))
+	}))
+	defer server.Close()
+
+	functionDone := make(chan struct{})
+	go func() {
+		defer close(functionDone)
+		var req, eof httpproto.Http
+		f.next(&req)
+		f.next(&eof)
+		t.Assert().True(eof.Eof)
+		f.send(&httpproto.Http{
+			Status: http.StatusSwitchingProtocols,
+			Headers: map[string]*httpproto.HeaderList{
+				"Sec-WebSocket-Protocol": {Header: []string{"chat"}},
+			},
+		})
+		for {
+			var frame httpproto.Frame
+			f.next(&frame)
+			if frame.Type == httpproto.Frame_CLOSE {
+				return
+			}
+			if string(frame.Data) == "close" {
+				f.send(&httpproto.Frame{Type: httpproto.Frame_CLOSE, CloseCode: 4000, Data: []byte("done")})
+				continue
+			}
+			f.send(&httpproto.Frame{Type: frame.Type, Data: bytes.ToUpper(frame.Data)})
+		}
+	}()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"chat"}}
+	ws, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	t.PanicOnErr(err)
+	defer ws.Close()
+	t.Assert().Equal("chat", ws.Subprotocol())
+
+	t.PanicOnErr(ws.WriteMessage(websocket.TextMessage, []byte("hello")))
+	messageType, data, err := ws.ReadMessage()
+	t.PanicOnErr(err)
+	t.Assert().Equal(websocket.TextMessage, messageType)
+	t.Assert().Equal("HELLO", string(data))
+
+	t.PanicOnErr(ws.WriteMessage(websocket.BinaryMessage, []byte{'a', 0}))
+	messageType, data, err = ws.ReadMessage()
+	t.PanicOnErr(err)
+	t.Assert().Equal(websocket.BinaryMessage, messageType)
+	t.Assert().Equal([]byte{'A', 0}, data)
+
+	t.PanicOnErr(ws.WriteMessage(websocket.TextMessage, []byte("close")))
+	_, _, err = ws.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	t.Assert().True(ok, err)
+	t.Assert().Equal(4000, closeErr.Code)
+	t.Assert().Equal("done", closeErr.Text)
+	<-functionDone
+}
@@ -103,3 +103,8 @@ func StartVinyl(name string) (vinyl *Vinyl, err error) {
 	err = vinyl.Cont.Start()
 	return
 }
+
+// Stop stops the database container, data is kept in the container's volume
+func (v *Vinyl) Stop() (err error) {
+	return v.Cont.Stop()
+}
@@ -0,0 +1,261 @@
+package kv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltBucket = []byte("kv")
+	// boltExpiryBucket holds the expiry time in unix nanoseconds of every key
+	// that was set with a ttl
+	boltExpiryBucket = []byte("kv_expiry")
+)
+
+// NewBoltStore opens the bolt database at path, creating it if it doesn't exist
+func NewBoltStore(path string) (Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err == bolt.ErrTimeout {
+		return nil, errors.Errorf("kv store at %s is in use by another process", path)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltExpiryBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, errors.WithStack(err)
+	}
+	bs := &BoltStore{db: db, stop: make(chan struct{}), done: make(chan struct{})}
+	if err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).ForEach(func(k, v []byte) error {
+			bs.size += int64(len(k) + len(v))
+			return nil
+		})
+	}); err != nil {
+		db.Close()
+		return nil, errors.WithStack(err)
+	}
+	go bs.sweeper()
+	return bs, nil
+}
+
+// BoltStore is a KV store that persists values to disk with bbolt
+type BoltStore struct {
+	// size is first so that it is 64-bit aligned for atomic operations
+	size int64
+	db   *bolt.DB
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// boltGet returns a key's value, or nil if it doesn't exist or has expired
+func boltGet(tx *bolt.Tx, key []byte, now time.Time) []byte {
+	v := tx.Bucket(boltBucket).Get(key)
+	if v != nil && boltExpired(tx, key, now) {
+		return nil
+	}
+	return v
+}
+
+func boltExpired(tx *bolt.Tx, key []byte, now time.Time) bool {
+	exp := tx.Bucket(boltExpiryBucket).Get(key)
+	return exp != nil && int64(binary.LittleEndian.Uint64(exp)) <= now.UnixNano()
+}
+
+// Get implements Get for BoltStore from the Store interface
+func (bs *BoltStore) Get(key []byte) (value []byte, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		v := boltGet(tx, key, time.Now())
+		if v == nil {
+			return ErrNoExist
+		}
+		// bolt values are only valid for the life of the transaction
+		value = append([]byte{}, v...)
+		return nil
+	})
+	return
+}
+
+// update runs fn in a write transaction, fn adds the change in size of the
+// store to delta which is applied once the transaction commits
+func (bs *BoltStore) update(fn func(tx *bolt.Tx, delta *int64) error) (err error) {
+	var delta int64
+	if err = bs.db.Update(func(tx *bolt.Tx) error {
+		return fn(tx, &delta)
+	}); err == nil {
+		atomic.AddInt64(&bs.size, delta)
+	}
+	return
+}
+
+func boltPut(tx *bolt.Tx, key []byte, value []byte, delta *int64) error {
+	b := tx.Bucket(boltBucket)
+	if old := b.Get(key); old != nil {
+		*delta -= int64(len(key) + len(old))
+	}
+	*delta += int64(len(key) + len(value))
+	return b.Put(key, value)
+}
+
+// Set implements Set for BoltStore from the Store interface
+func (bs *BoltStore) Set(key []byte, value []byte) (err error) {
+	return bs.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL implements SetWithTTL for BoltStore from the Store interface
+func (bs *BoltStore) SetWithTTL(key []byte, value []byte, ttl time.Duration) (err error) {
+	return bs.update(func(tx *bolt.Tx, delta *int64) error {
+		if err := boltPut(tx, key, value, delta); err != nil {
+			return err
+		}
+		if ttl <= 0 {
+			return tx.Bucket(boltExpiryBucket).Delete(key)
+		}
+		exp := make([]byte, 8)
+		binary.LittleEndian.PutUint64(exp, uint64(time.Now().Add(ttl).UnixNano()))
+		return tx.Bucket(boltExpiryBucket).Put(key, exp)
+	})
+}
+
+func boltDelete(tx *bolt.Tx, key []byte, delta *int64) error {
+	b := tx.Bucket(boltBucket)
+	if old := b.Get(key); old != nil {
+		*delta -= int64(len(key) + len(old))
+	}
+	if err := b.Delete(key); err != nil {
+		return err
+	}
+	return tx.Bucket(boltExpiryBucket).Delete(key)
+}
+
+// Delete implements Delete for BoltStore from the Store interface
+func (bs *BoltStore) Delete(key []byte) (err error) {
+	return bs.update(func(tx *bolt.Tx, delta *int64) error {
+		return boltDelete(tx, key, delta)
+	})
+}
+
+// Exists implements Exists for BoltStore from the Store interface
+func (bs *BoltStore) Exists(key []byte) (exists bool, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		exists = boltGet(tx, key, time.Now()) != nil
+		return nil
+	})
+	return
+}
+
+// Scan implements Scan for BoltStore from the Store interface
+func (bs *BoltStore) Scan(prefix []byte, limit int) (pairs []KeyValue, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		now := time.Now()
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			if limit > 0 && len(pairs) == limit {
+				break
+			}
+			if boltExpired(tx, k, now) {
+				continue
+			}
+			pairs = append(pairs, KeyValue{
+				Key:   append([]byte{}, k...),
+				Value: append([]byte{}, v...),
+			})
+		}
+		return nil
+	})
+	return
+}
+
+// CompareAndSwap implements CompareAndSwap for BoltStore from the Store interface
+func (bs *BoltStore) CompareAndSwap(key []byte, old []byte, value []byte) (swapped bool, err error) {
+	err = bs.update(func(tx *bolt.Tx, delta *int64) error {
+		current := boltGet(tx, key, time.Now())
+		if old == nil && current != nil || old != nil && (current == nil || !bytes.Equal(current, old)) {
+			return nil
+		}
+		swapped = true
+		if err := boltPut(tx, key, value, delta); err != nil {
+			return err
+		}
+		return tx.Bucket(boltExpiryBucket).Delete(key)
+	})
+	return
+}
+
+// Size implements Size for BoltStore from the Store interface
+func (bs *BoltStore) Size() (size int64, err error) {
+	return atomic.LoadInt64(&bs.size), nil
+}
+
+// EntrySize implements EntrySize for BoltStore from the Store interface
+func (bs *BoltStore) EntrySize(key []byte) (size int64, err error) {
+	err = bs.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(boltBucket).Get(key); v != nil {
+			size = int64(len(key) + len(v))
+		}
+		return nil
+	})
+	return
+}
+
+// sweep removes all expired keys
+func (bs *BoltStore) sweep() (err error) {
+	return bs.update(func(tx *bolt.Tx, delta *int64) error {
+		now := time.Now()
+		expired := [][]byte{}
+		err := tx.Bucket(boltExpiryBucket).ForEach(func(k, v []byte) error {
+			if int64(binary.LittleEndian.Uint64(v)) <= now.UnixNano() {
+				expired = append(expired, append([]byte{}, k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := boltDelete(tx, k, delta); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BoltStore) sweeper() {
+	defer close(bs.done)
+	ticker := time.NewTicker(SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			bs.sweep()
+		case <-bs.stop:
+			return
+		}
+	}
+}
+
+// Close implements Close for BoltStore from the Store interface
+func (bs *BoltStore) Close() (err error) {
+	bs.closeOnce.Do(func() { close(bs.stop) })
+	<-bs.done
+	return bs.db.Close()
+}
@@ -0,0 +1,95 @@
+package kv
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBoltSetAndGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "kv", "kv.db")
+
+	bs, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("key")
+	value := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	if err := bs.Set(key, value); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bs.Get([]byte("noexist")); err != ErrNoExist {
+		t.Fatal("error should be ErrNoExist")
+	}
+	if _, err := NewBoltStore(path); err == nil {
+		t.Fatal("store should be locked while it's open")
+	}
+	if err := bs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// values are still around after reopening the store
+	bs, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Close()
+	v, err := bs.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, value) {
+		t.Fatal("bytes aren't equal")
+	}
+	if size, _ := bs.Size(); size != int64(len(key)+len(value)) {
+		t.Fatal("size should be counted when the store is opened", size)
+	}
+}
+
+func TestBoltOperations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bs, err := NewBoltStore(filepath.Join(dir, "kv.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Close()
+	testStoreOperations(t, bs)
+}
+
+func TestBoltTTL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewBoltStore(filepath.Join(dir, "kv.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	bs := s.(*BoltStore)
+	testStoreTTL(t, bs, func() int {
+		if err := bs.sweep(); err != nil {
+			t.Fatal(err)
+		}
+		left := 0
+		bs.db.View(func(tx *bolt.Tx) error {
+			left = tx.Bucket(boltBucket).Stats().KeyN
+			return nil
+		})
+		return left
+	})
+}
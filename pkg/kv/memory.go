@@ -1,29 +1,187 @@
 package kv
 
 import (
+	"bytes"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
+// NewMemoryStore creates an in-memory store and starts a sweeper that removes
+// expired keys until the store is closed
 func NewMemoryStore() Store {
-	return &MemoryStore{}
+	ms := &MemoryStore{
+		store: make(map[string]memoryEntry),
+		stop:  make(chan struct{}),
+	}
+	go ms.sweeper()
+	return ms
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
 }
 
 // MemoryStore is an in-memory KV store
 type MemoryStore struct {
-	store sync.Map
+	mutex sync.RWMutex
+	store map[string]memoryEntry
+	size  int64
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// put and remove must be called while holding the write lock
+func (ms *MemoryStore) put(key string, e memoryEntry) {
+	ms.remove(key)
+	ms.store[key] = e
+	ms.size += int64(len(key) + len(e.value))
+}
+
+func (ms *MemoryStore) remove(key string) {
+	if old, ok := ms.store[key]; ok {
+		ms.size -= int64(len(key) + len(old.value))
+		delete(ms.store, key)
+	}
+}
+
+// lookup must be called while holding the mutex
+func (ms *MemoryStore) lookup(key string) (value []byte, ok bool) {
+	e, ok := ms.store[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// EntrySize implements EntrySize for MemoryStore from the Store interface
+func (ms *MemoryStore) EntrySize(key []byte) (size int64, err error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	if e, ok := ms.store[string(key)]; ok {
+		size = int64(len(key) + len(e.value))
+	}
+	return
 }
 
 // Get implements Get for MemoryStore from the Store interface
 func (ms *MemoryStore) Get(key []byte) (value []byte, err error) {
-	v, ok := ms.store.Load(string(key))
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	v, ok := ms.lookup(string(key))
 	if !ok {
 		return nil, ErrNoExist
 	}
-	return v.([]byte), nil
+	return v, nil
 }
 
 // Set implements Set for MemoryStore from the Store interface
 func (ms *MemoryStore) Set(key []byte, value []byte) (err error) {
-	ms.store.Store(string(key), value)
+	return ms.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL implements SetWithTTL for MemoryStore from the Store interface
+func (ms *MemoryStore) SetWithTTL(key []byte, value []byte, ttl time.Duration) (err error) {
+	e := memoryEntry{value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.put(string(key), e)
+	return nil
+}
+
+// Delete implements Delete for MemoryStore from the Store interface
+func (ms *MemoryStore) Delete(key []byte) (err error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.remove(string(key))
+	return nil
+}
+
+// Exists implements Exists for MemoryStore from the Store interface
+func (ms *MemoryStore) Exists(key []byte) (exists bool, err error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	_, exists = ms.lookup(string(key))
+	return
+}
+
+// Scan implements Scan for MemoryStore from the Store interface
+func (ms *MemoryStore) Scan(prefix []byte, limit int) (pairs []KeyValue, err error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	now := time.Now()
+	keys := []string{}
+	for k, e := range ms.store {
+		if strings.HasPrefix(k, string(prefix)) && !e.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	for _, k := range keys {
+		pairs = append(pairs, KeyValue{Key: []byte(k), Value: ms.store[k].value})
+	}
+	return
+}
+
+// CompareAndSwap implements CompareAndSwap for MemoryStore from the Store interface
+func (ms *MemoryStore) CompareAndSwap(key []byte, old []byte, value []byte) (swapped bool, err error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	current, exists := ms.lookup(string(key))
+	if old == nil && exists || old != nil && (!exists || !bytes.Equal(current, old)) {
+		return false, nil
+	}
+	ms.put(string(key), memoryEntry{value: value})
+	return true, nil
+}
+
+// Size implements Size for MemoryStore from the Store interface
+func (ms *MemoryStore) Size() (size int64, err error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	return ms.size, nil
+}
+
+// sweep removes all expired keys
+func (ms *MemoryStore) sweep() {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	now := time.Now()
+	for k, e := range ms.store {
+		if e.expired(now) {
+			ms.remove(k)
+		}
+	}
+}
+
+func (ms *MemoryStore) sweeper() {
+	ticker := time.NewTicker(SweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ms.sweep()
+		case <-ms.stop:
+			return
+		}
+	}
+}
+
+// Close implements Close for MemoryStore from the Store interface
+func (ms *MemoryStore) Close() (err error) {
+	ms.closeOnce.Do(func() { close(ms.stop) })
 	return nil
 }
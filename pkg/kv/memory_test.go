@@ -32,3 +32,18 @@ func TestMemorySetAndGet(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryOperations(t *testing.T) {
+	testStoreOperations(t, NewMemoryStore())
+}
+
+func TestMemoryTTL(t *testing.T) {
+	ms := NewMemoryStore().(*MemoryStore)
+	defer ms.Close()
+	testStoreTTL(t, ms, func() int {
+		ms.sweep()
+		ms.mutex.RLock()
+		defer ms.mutex.RUnlock()
+		return len(ms.store)
+	})
+}
@@ -0,0 +1,67 @@
+package kv
+
+import (
+	"sync"
+	"time"
+)
+
+// NewQuotaStore wraps a store so that writes fail with ErrQuotaExceeded if
+// they would make the total size of its keys and values larger than quota bytes
+func NewQuotaStore(store Store, quota int64) Store {
+	return &QuotaStore{Store: store, quota: quota}
+}
+
+// QuotaStore is a Store that limits the size of the store it wraps
+type QuotaStore struct {
+	Store
+	quota int64
+
+	// writes are serialized so that two writes can't both fit under the quota
+	mutex sync.Mutex
+}
+
+// fits checks if setting key to value would keep the store within its quota.
+// Must be called while holding the mutex
+func (qs *QuotaStore) fits(key []byte, value []byte) (err error) {
+	size, err := qs.Store.Size()
+	if err != nil {
+		return err
+	}
+	// the old entry is counted by Size even if it has expired, so it's
+	// subtracted whether or not Get would find it. It's read after Size so
+	// that a sweep in between can only make us overestimate
+	old, err := qs.Store.EntrySize(key)
+	if err != nil {
+		return err
+	}
+	size += int64(len(key)+len(value)) - old
+	if size > qs.quota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Set implements Set for QuotaStore from the Store interface
+func (qs *QuotaStore) Set(key []byte, value []byte) (err error) {
+	return qs.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL implements SetWithTTL for QuotaStore from the Store interface
+func (qs *QuotaStore) SetWithTTL(key []byte, value []byte, ttl time.Duration) (err error) {
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+	if err = qs.fits(key, value); err != nil {
+		return
+	}
+	return qs.Store.SetWithTTL(key, value, ttl)
+}
+
+// CompareAndSwap implements CompareAndSwap for QuotaStore from the Store interface
+func (qs *QuotaStore) CompareAndSwap(key []byte, old []byte, value []byte) (swapped bool, err error) {
+	qs.mutex.Lock()
+	defer qs.mutex.Unlock()
+	if err = qs.fits(key, value); err != nil {
+		return
+	}
+	return qs.Store.CompareAndSwap(key, old, value)
+}
@@ -0,0 +1,68 @@
+package kv
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQuotaStore(t *testing.T) {
+	qs := NewQuotaStore(NewMemoryStore(), 10)
+	defer qs.Close()
+
+	if err := qs.Set([]byte("a"), []byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+	if err := qs.Set([]byte("b"), []byte("12345")); err != ErrQuotaExceeded {
+		t.Fatal("store should be over quota", err)
+	}
+	// overwriting a key only counts the difference in size
+	if err := qs.Set([]byte("a"), []byte("123456789")); err != nil {
+		t.Fatal(err)
+	}
+	if swapped, err := qs.CompareAndSwap([]byte("c"), nil, []byte("1")); err != ErrQuotaExceeded || swapped {
+		t.Fatal("swap should be over quota", err)
+	}
+	if err := qs.Delete([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := qs.Size(); size != 0 {
+		t.Fatal("size should be 0 after deleting every key", size)
+	}
+	if err := qs.Set([]byte("b"), []byte("12345")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuotaStoreExpiredKeys(t *testing.T) {
+	qs := NewQuotaStore(NewMemoryStore(), 10)
+	defer qs.Close()
+
+	if err := qs.SetWithTTL([]byte("a"), []byte("123456789"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	// the expired value is still counted until it's swept, but it's replaced
+	if err := qs.Set([]byte("a"), []byte("123456789")); err != nil {
+		t.Fatal("overwriting an expired key should fit", err)
+	}
+}
+
+func TestQuotaStoreConcurrentWrites(t *testing.T) {
+	qs := NewQuotaStore(NewMemoryStore(), 100)
+	defer qs.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = qs.Set([]byte(fmt.Sprintf("key/%02d", i)), []byte("value"))
+		}(i)
+	}
+	wg.Wait()
+	if size, _ := qs.Size(); size > 100 {
+		t.Fatal("concurrent writes should stay within the quota", size)
+	}
+}
@@ -3,12 +3,39 @@ package kv
 import (
 	"encoding/binary"
 	"errors"
+	"time"
 )
 
 // Store is the interface for getting and setting
 type Store interface {
 	Get(key []byte) (value []byte, err error)
 	Set(key []byte, value []byte) (err error)
+	// SetWithTTL sets a value that expires after ttl. Expired keys are treated
+	// as if they don't exist. A ttl of 0 never expires
+	SetWithTTL(key []byte, value []byte, ttl time.Duration) (err error)
+	// Delete removes a key, deleting a key that doesn't exist is not an error
+	Delete(key []byte) (err error)
+	Exists(key []byte) (exists bool, err error)
+	// Scan returns keys and values that start with prefix in key order. A limit
+	// of 0 returns all matching keys
+	Scan(prefix []byte, limit int) (pairs []KeyValue, err error)
+	// CompareAndSwap sets key to value only if its current value is equal to old.
+	// If old is nil the value is only set if the key doesn't exist
+	CompareAndSwap(key []byte, old []byte, value []byte) (swapped bool, err error)
+	// Size returns the total size in bytes of all keys and values. Expired keys
+	// are counted until they are swept
+	Size() (size int64, err error)
+	// EntrySize returns the size in bytes of key and its value as it's counted
+	// by Size, so expired keys that haven't been swept are included. It's 0 if
+	// the key doesn't exist
+	EntrySize(key []byte) (size int64, err error)
+	Close() (err error)
+}
+
+// KeyValue is a key and its value
+type KeyValue struct {
+	Key   []byte
+	Value []byte
 }
 
 var (
@@ -17,6 +44,11 @@ var (
 
 	errNoSpaceForByteSize = errors.New("invalid input length for key/value string")
 	errInvalidSize        = errors.New("key length is longer than input string")
+	errInvalidLimit       = errors.New("scan limit must be 4 bytes")
+	errInvalidTTL         = errors.New("ttl must be 8 bytes")
+
+	// ErrQuotaExceeded the write would make the store larger than its quota
+	ErrQuotaExceeded = errors.New("kv store quota exceeded")
 
 	// ErrKeyTooLarge key is too large
 	ErrKeyTooLarge = errors.New("key values can't be greater than 10,000")
@@ -61,3 +93,151 @@ func WriteKeyAndValue(key []byte, value []byte) (out []byte, err error) {
 	out = append(out, value...)
 	return
 }
+
+// SweepInterval is how often stores remove expired keys
+var SweepInterval = time.Minute
+
+// ttlFlag is set on the key length of a set request when the request has a
+// time-to-live. Keys can't be larger than 10,000 bytes so the bit is never part
+// of a real key length
+const ttlFlag = 0x8000
+
+// WriteKeyValueAndTTL writes a set request with a time-to-live. The ttl is
+// written in milliseconds after the key length, which has its high bit set:
+// |uint16 len of key with 0x8000 set|uint64 ttl in ms|key bytes|value bytes|
+// A ttl of 0 writes a plain key and value
+func WriteKeyValueAndTTL(key []byte, value []byte, ttl time.Duration) (out []byte, err error) {
+	if out, err = WriteKeyAndValue(key, value); err != nil || ttl <= 0 {
+		return
+	}
+	binary.LittleEndian.PutUint16(out, uint16(len(key))|ttlFlag)
+	ttlBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(ttlBytes, uint64(ttl/time.Millisecond))
+	return append(out[:2], append(ttlBytes, out[2:]...)...), nil
+}
+
+// ExtractKeyValueAndTTL parses a set request written by WriteKeyAndValue or
+// WriteKeyValueAndTTL. The ttl is 0 if the request doesn't have one
+func ExtractKeyValueAndTTL(input []byte) (key []byte, value []byte, ttl time.Duration, err error) {
+	if len(input) < 2 || binary.LittleEndian.Uint16(input[:2])&ttlFlag == 0 {
+		key, value, err = ExtractKeyAndValue(input)
+		return
+	}
+	if len(input) < 10 {
+		err = errInvalidTTL
+		return
+	}
+	ttl = time.Duration(binary.LittleEndian.Uint64(input[2:10])) * time.Millisecond
+	ln := binary.LittleEndian.Uint16(input[:2]) &^ ttlFlag
+	input = input[10:]
+	if int(ln) > len(input) {
+		err = errInvalidSize
+		return
+	}
+	return input[:ln], input[ln:], ttl, nil
+}
+
+// noOldValue is written in place of the old value length in a compare and swap
+// request when the key must not exist
+const noOldValue = 0xffffffff
+
+// WriteScanRequest writes a prefix and limit in the key/value format with the
+// limit as a uint32 little endian value
+func WriteScanRequest(prefix []byte, limit int) (out []byte, err error) {
+	limitBytes := make([]byte, 4)
+	binary.LittleEndian.PutUint32(limitBytes, uint32(limit))
+	return WriteKeyAndValue(prefix, limitBytes)
+}
+
+// ExtractScanRequest parses bytes written by WriteScanRequest. A missing limit
+// is treated as no limit
+func ExtractScanRequest(input []byte) (prefix []byte, limit int, err error) {
+	prefix, limitBytes, err := ExtractKeyAndValue(input)
+	if err != nil || len(limitBytes) == 0 {
+		return
+	}
+	if len(limitBytes) != 4 {
+		err = errInvalidLimit
+		return
+	}
+	limit = int(binary.LittleEndian.Uint32(limitBytes))
+	return
+}
+
+// WriteKeyValues writes a list of keys and values, each pair is written in the
+// key/value format prefixed with its uint32 little endian length:
+// |uint32 len of pair|uint16 len of key|key bytes|value bytes|...
+func WriteKeyValues(pairs []KeyValue) (out []byte, err error) {
+	out = []byte{}
+	for _, pair := range pairs {
+		b, err := WriteKeyAndValue(pair.Key, pair.Value)
+		if err != nil {
+			return nil, err
+		}
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(b)))
+		out = append(out, size...)
+		out = append(out, b...)
+	}
+	return
+}
+
+// ExtractKeyValues parses bytes written by WriteKeyValues
+func ExtractKeyValues(input []byte) (pairs []KeyValue, err error) {
+	for len(input) > 0 {
+		if len(input) < 4 {
+			return nil, errNoSpaceForByteSize
+		}
+		ln := binary.LittleEndian.Uint32(input[:4])
+		input = input[4:]
+		if int(ln) > len(input) {
+			return nil, errInvalidSize
+		}
+		key, value, err := ExtractKeyAndValue(input[:ln])
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, KeyValue{Key: key, Value: value})
+		input = input[ln:]
+	}
+	return
+}
+
+// WriteCompareAndSwap writes a compare and swap request structured like so:
+// |uint16 len of key|key bytes|uint32 len of old value|old value bytes|value bytes|
+// A nil old value is written with a length of 0xffffffff
+func WriteCompareAndSwap(key []byte, old []byte, value []byte) (out []byte, err error) {
+	if len(old) > 100000 {
+		err = ErrValueTooLarge
+		return
+	}
+	oldLen := make([]byte, 4)
+	if old == nil {
+		binary.LittleEndian.PutUint32(oldLen, noOldValue)
+	} else {
+		binary.LittleEndian.PutUint32(oldLen, uint32(len(old)))
+	}
+	return WriteKeyAndValue(key, append(append(oldLen, old...), value...))
+}
+
+// ExtractCompareAndSwap parses bytes written by WriteCompareAndSwap
+func ExtractCompareAndSwap(input []byte) (key []byte, old []byte, value []byte, err error) {
+	key, rest, err := ExtractKeyAndValue(input)
+	if err != nil {
+		return
+	}
+	if len(rest) < 4 {
+		err = errNoSpaceForByteSize
+		return
+	}
+	oldLen := binary.LittleEndian.Uint32(rest[:4])
+	rest = rest[4:]
+	if oldLen == noOldValue {
+		return key, nil, rest, nil
+	}
+	if int(oldLen) > len(rest) {
+		err = errInvalidSize
+		return
+	}
+	return key, rest[:oldLen], rest[oldLen:], nil
+}
@@ -3,6 +3,7 @@ package kv
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"embly/pkg/randy"
 )
@@ -68,3 +69,198 @@ func TestExtract(t *testing.T) {
 	}
 
 }
+
+// testStoreOperations runs the same checks against any Store implementation
+func testStoreOperations(t *testing.T, s Store) {
+	for _, k := range []string{"user/2", "user/1", "user/3", "session/1"} {
+		if err := s.Set([]byte(k), []byte("v"+k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if exists, err := s.Exists([]byte("user/1")); err != nil || !exists {
+		t.Fatal("user/1 should exist", err)
+	}
+	if err := s.Delete([]byte("user/1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete([]byte("user/1")); err != nil {
+		t.Fatal("deleting a missing key shouldn't error", err)
+	}
+	if exists, err := s.Exists([]byte("user/1")); err != nil || exists {
+		t.Fatal("user/1 should not exist", err)
+	}
+
+	pairs, err := s.Scan([]byte("user/"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || string(pairs[0].Key) != "user/2" || string(pairs[1].Value) != "vuser/3" {
+		t.Fatal("unexpected scan result", pairs)
+	}
+	if pairs, _ = s.Scan([]byte("user/"), 1); len(pairs) != 1 {
+		t.Fatal("scan should respect the limit", pairs)
+	}
+	if pairs, _ = s.Scan([]byte("nope"), 0); len(pairs) != 0 {
+		t.Fatal("scan should not match anything", pairs)
+	}
+
+	key := []byte("counter")
+	if swapped, err := s.CompareAndSwap(key, nil, []byte("1")); err != nil || !swapped {
+		t.Fatal("swap of a new key should succeed", err)
+	}
+	if swapped, _ := s.CompareAndSwap(key, nil, []byte("1")); swapped {
+		t.Fatal("swap of an existing key with nil old value should fail")
+	}
+	if swapped, _ := s.CompareAndSwap(key, []byte("2"), []byte("3")); swapped {
+		t.Fatal("swap with the wrong old value should fail")
+	}
+	if swapped, _ := s.CompareAndSwap(key, []byte("1"), []byte("2")); !swapped {
+		t.Fatal("swap with the right old value should succeed")
+	}
+	if v, _ := s.Get(key); string(v) != "2" {
+		t.Fatal("value should have been swapped", v)
+	}
+	if swapped, _ := s.CompareAndSwap([]byte("missing"), []byte{}, []byte("1")); swapped {
+		t.Fatal("swap of a missing key with an empty old value should fail")
+	}
+
+	pairs, _ = s.Scan(nil, 0)
+	var expected int64
+	for _, pair := range pairs {
+		expected += int64(len(pair.Key) + len(pair.Value))
+	}
+	if size, err := s.Size(); err != nil || size != expected {
+		t.Fatal("size should match the size of every key and value", size, expected, err)
+	}
+}
+
+// testStoreTTL checks that expired keys are hidden, sweep should remove expired
+// keys from the store and return how many keys are left
+func testStoreTTL(t *testing.T, s Store, sweep func() int) {
+	if err := s.SetWithTTL([]byte("token/1"), []byte("a"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetWithTTL([]byte("token/2"), []byte("b"), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetWithTTL([]byte("token/3"), []byte("c"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// setting a key without a ttl removes its expiry
+	if err := s.Set([]byte("token/3"), []byte("c")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := s.Get([]byte("token/1")); err != ErrNoExist {
+		t.Fatal("expired key should not exist", err)
+	}
+	if exists, _ := s.Exists([]byte("token/1")); exists {
+		t.Fatal("expired key should not exist")
+	}
+	if v, err := s.Get([]byte("token/2")); err != nil || string(v) != "b" {
+		t.Fatal("key should not have expired yet", err)
+	}
+	if pairs, _ := s.Scan([]byte("token/"), 0); len(pairs) != 2 {
+		t.Fatal("scan should skip expired keys", pairs)
+	}
+	if swapped, _ := s.CompareAndSwap([]byte("token/1"), nil, []byte("d")); !swapped {
+		t.Fatal("swap of an expired key with nil old value should succeed")
+	}
+	if err := s.SetWithTTL([]byte("token/1"), []byte("a"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if size, err := s.EntrySize([]byte("token/1")); err != nil || size != int64(len("token/1a")) {
+		t.Fatal("entry size should count expired keys until they are swept", size, err)
+	}
+	if left := sweep(); left != 2 {
+		t.Fatal("sweep should remove expired keys, keys left:", left)
+	}
+}
+
+func TestKeyValueEncodings(t *testing.T) {
+	{
+		b, err := WriteKeyValueAndTTL([]byte("key"), []byte("value"), 90*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, value, ttl, err := ExtractKeyValueAndTTL(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(key) != "key" || string(value) != "value" || ttl != 90*time.Second {
+			t.Fatal("key, value and ttl don't match", key, value, ttl)
+		}
+		b, _ = WriteKeyAndValue([]byte("key"), []byte("value"))
+		if _, _, ttl, err = ExtractKeyValueAndTTL(b); err != nil || ttl != 0 {
+			t.Fatal("plain set requests should not have a ttl", err)
+		}
+		b, _ = WriteKeyValueAndTTL([]byte("key"), []byte("value"), 0)
+		if !bytes.Equal(b, []byte("\x03\x00keyvalue")) {
+			t.Fatal("a ttl of 0 should write a plain key and value", b)
+		}
+		if _, _, _, err = ExtractKeyValueAndTTL([]byte{3, 0x80, 0}); err != errInvalidTTL {
+			t.Fatal("short ttl should error", err)
+		}
+	}
+
+	{
+		b, err := WriteScanRequest([]byte("prefix"), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix, limit, err := ExtractScanRequest(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(prefix) != "prefix" || limit != 10 {
+			t.Fatal("scan request doesn't match", prefix, limit)
+		}
+		b, _ = WriteKeyAndValue([]byte("prefix"), nil)
+		if _, limit, err = ExtractScanRequest(b); err != nil || limit != 0 {
+			t.Fatal("a missing limit should mean no limit", err)
+		}
+	}
+
+	{
+		in := []KeyValue{{[]byte("a"), []byte("1")}, {[]byte("bb"), []byte{}}}
+		b, err := WriteKeyValues(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := ExtractKeyValues(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != 2 || string(out[1].Key) != "bb" || string(out[0].Value) != "1" {
+			t.Fatal("key values don't match", out)
+		}
+		if _, err := ExtractKeyValues(b[:len(b)-1]); err == nil {
+			t.Fatal("truncated input should error")
+		}
+	}
+
+	{
+		b, err := WriteCompareAndSwap([]byte("key"), nil, []byte("new"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, old, value, err := ExtractCompareAndSwap(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(key) != "key" || old != nil || string(value) != "new" {
+			t.Fatal("compare and swap doesn't match", key, old, value)
+		}
+		b, _ = WriteCompareAndSwap([]byte("key"), []byte{}, []byte("new"))
+		if _, old, _, _ = ExtractCompareAndSwap(b); old == nil || len(old) != 0 {
+			t.Fatal("empty old value should not be nil")
+		}
+		b, _ = WriteCompareAndSwap([]byte("key"), []byte("old"), []byte("new"))
+		if _, old, value, _ = ExtractCompareAndSwap(b); string(old) != "old" || string(value) != "new" {
+			t.Fatal("compare and swap doesn't match", old, value)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package localca creates a certificate authority on the local machine and uses
+// it to sign certificates for https gateways during development. The authority
+// and the certificates are cached so that the authority only needs to be
+// trusted once.
+package localca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	caValidity = 10 * 365 * 24 * time.Hour
+	// browsers reject certificates that are valid for longer than 825 days
+	certValidity = 825 * 24 * time.Hour
+	// certificates are regenerated when they are this close to expiring
+	renewBefore = 30 * 24 * time.Hour
+)
+
+// Authority is a local certificate authority
+type Authority struct {
+	dir  string
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// DefaultDir is the directory in the user's home directory that the authority
+// is kept in
+func DefaultDir() (dir string, err error) {
+	usr, err := user.Current()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return filepath.Join(usr.HomeDir, ".embly", "certs"), nil
+}
+
+// Load reads the authority in dir, creating it if it doesn't exist yet
+func Load(dir string) (ca *Authority, err error) {
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ca = &Authority{dir: dir}
+	certFile, keyFile := ca.CertFile(), filepath.Join(dir, "ca-key.pem")
+	if _, err = os.Stat(certFile); os.IsNotExist(err) {
+		if err = ca.create(certFile, keyFile); err != nil {
+			return nil, err
+		}
+		return ca, nil
+	}
+	if ca.cert, ca.key, err = readKeyPair(certFile, keyFile); err != nil {
+		return nil, errors.Wrap(err, "error reading local certificate authority")
+	}
+	return ca, nil
+}
+
+// CertFile is the authority's certificate, which needs to be trusted by
+// browsers for them to accept the certificates it signs
+func (ca *Authority) CertFile() string {
+	return filepath.Join(ca.dir, "ca.pem")
+}
+
+func (ca *Authority) create(certFile, keyFile string) (err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{"embly development"},
+			CommonName:   "embly local certificate authority",
+		},
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	if ca.cert, err = sign(template, template, key.Public(), key); err != nil {
+		return err
+	}
+	ca.key = key
+	return writeKeyPair(certFile, keyFile, ca.cert, key)
+}
+
+// Certificate returns a certificate for hosts signed by the authority. Hosts
+// can be domain names, wildcard domains like "*.example.com" or ip addresses.
+// Certificates are reused until they are close to expiring
+func (ca *Authority) Certificate(hosts []string) (cert tls.Certificate, err error) {
+	hosts = append([]string{}, hosts...)
+	sort.Strings(hosts)
+	sum := sha256.Sum256([]byte(strings.Join(hosts, ",")))
+	name := fmt.Sprintf("%x", sum[:8])
+	certFile := filepath.Join(ca.dir, name+".pem")
+	keyFile := filepath.Join(ca.dir, name+"-key.pem")
+
+	if leaf, _, err := readKeyPair(certFile, keyFile); err == nil &&
+		time.Now().Add(renewBefore).Before(leaf.NotAfter) && leaf.CheckSignatureFrom(ca.cert) == nil {
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return cert, errors.WithStack(err)
+	}
+	template := &x509.Certificate{
+		Subject: pkix.Name{
+			Organization: []string{"embly development"},
+			CommonName:   hosts[0],
+		},
+		NotAfter:    time.Now().Add(certValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	leaf, err := sign(template, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		return cert, err
+	}
+	if err = writeKeyPair(certFile, keyFile, leaf, key); err != nil {
+		return cert, err
+	}
+	return tls.LoadX509KeyPair(certFile, keyFile)
+}
+
+func sign(template, parent *x509.Certificate, pub crypto.PublicKey, key crypto.Signer) (cert *x509.Certificate, err error) {
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// allow for clock skew between machines
+	template.NotBefore = time.Now().Add(-time.Hour)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	return cert, errors.WithStack(err)
+}
+
+func writeKeyPair(certFile, keyFile string, cert *x509.Certificate, key *ecdsa.PrivateKey) (err error) {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0644)
+	return errors.WithStack(err)
+}
+
+func readKeyPair(certFile, keyFile string) (cert *x509.Certificate, key crypto.Signer, err error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if cert, err = x509.ParseCertificate(pair.Certificate[0]); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, errors.Errorf("%s is not a signing key", keyFile)
+	}
+	return cert, key, nil
+}
@@ -0,0 +1,39 @@
+package localca
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"embly/pkg/tester"
+)
+
+func TestCertificate(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "localca")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+
+	ca, err := Load(dir)
+	t.PanicOnErr(err)
+	cert, err := ca.Certificate([]string{"localhost", "127.0.0.1", "*.example.com"})
+	t.PanicOnErr(err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	t.PanicOnErr(err)
+	for _, host := range []string{"localhost", "127.0.0.1", "foo.example.com"} {
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: host, Roots: pool})
+		t.Assert().NoError(err, host)
+	}
+
+	// the authority and certificate are reused once they are created
+	again, err := Load(dir)
+	t.PanicOnErr(err)
+	t.Assert().Equal(ca.cert.Raw, again.cert.Raw)
+	cached, err := again.Certificate([]string{"*.example.com", "localhost", "127.0.0.1"})
+	t.PanicOnErr(err)
+	t.Assert().Equal(cert.Certificate, cached.Certificate)
+}
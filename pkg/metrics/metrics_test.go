@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"embly/pkg/tester"
+)
+
+func TestRegistry(te *testing.T) {
+	t := tester.New(te)
+	r := NewRegistry()
+	requests := r.NewCounter("requests_total", "Requests\nhandled", "route", "status")
+	r.NewGaugeFunc("live", "Live things", func() float64 { return 3 })
+	latency := r.NewHistogram("latency_seconds", "Latency", []float64{0.1, 1})
+
+	requests.Inc("/users/:id", "200")
+	requests.Inc("/users/:id", "200")
+	requests.Add(1, `/"quoted"`, "500")
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(5)
+
+	t.Assert().Equal(float64(2), requests.Value("/users/:id", "200"))
+	t.Assert().Equal(uint64(3), latency.Count())
+
+	var buf bytes.Buffer
+	t.PanicOnErr(r.Write(&buf))
+	t.Assert().Equal(`# HELP requests_total Requests\nhandled
+# TYPE requests_total counter
+requests_total{route="/\"quoted\"",status="500"} 1
+requests_total{route="/users/:id",status="200"} 2
+# HELP live Live things
+# TYPE live gauge
+live 3
+# HELP latency_seconds Latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 1
+latency_seconds_bucket{le="1"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 5.55
+latency_seconds_count 3
+`, buf.String())
+
+	t.Assert().Panics(func() { requests.Inc("/") })
+}
@@ -19,7 +19,7 @@ func WriteMessage(consumer io.Writer, msg proto.Message) (err error) {
 	}
 	b = append(size, b...)
 	ln, err := consumer.Write(b)
-	if ln != len(b) {
+	if err == nil && ln != len(b) {
 		fmt.Println(consumer, "didn't write everything!")
 	}
 	return
@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileExporter writes each span as a json object on its own line
+type FileExporter struct {
+	mutex sync.Mutex
+	w     io.WriteCloser
+}
+
+// NewFileExporter appends spans to the file at path, creating it if it doesn't
+// exist
+func NewFileExporter(path string) (*FileExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening trace file")
+	}
+	return &FileExporter{w: f}, nil
+}
+
+// fileSpan is the json written for each span
+type fileSpan struct {
+	TraceID    string                 `json:"trace_id"`
+	SpanID     string                 `json:"span_id"`
+	ParentID   string                 `json:"parent_span_id,omitempty"`
+	Name       string                 `json:"name"`
+	Kind       string                 `json:"kind"`
+	Start      string                 `json:"start"`
+	End        string                 `json:"end"`
+	Duration   float64                `json:"duration_ms"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+var kindNames = map[SpanKind]string{
+	SpanKindInternal: "internal",
+	SpanKindServer:   "server",
+	SpanKindClient:   "client",
+}
+
+// Export writes spans to the file
+func (fe *FileExporter) Export(spans []SpanData) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		fs := fileSpan{
+			TraceID:  s.Context.TraceID.String(),
+			SpanID:   s.Context.SpanID.String(),
+			Name:     s.Name,
+			Kind:     kindNames[s.Kind],
+			Start:    s.Start.UTC().Format(time.RFC3339Nano),
+			End:      s.End.UTC().Format(time.RFC3339Nano),
+			Duration: float64(s.End.Sub(s.Start)) / float64(time.Millisecond),
+			Error:    s.Error,
+		}
+		if s.Parent.IsValid() {
+			fs.ParentID = s.Parent.String()
+		}
+		if len(s.Attributes) > 0 {
+			fs.Attributes = make(map[string]interface{}, len(s.Attributes))
+			for _, a := range s.Attributes {
+				fs.Attributes[a.Key] = a.Value
+			}
+		}
+		if err := enc.Encode(fs); err != nil {
+			return err
+		}
+	}
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	_, err := fe.w.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the file
+func (fe *FileExporter) Close() error {
+	return fe.w.Close()
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP over http
+// using the json encoding
+type OTLPExporter struct {
+	url     string
+	service string
+	client  *http.Client
+}
+
+// NewOTLPExporter sends spans to the collector at endpoint, like
+// http://localhost:4318. The /v1/traces path is added if the endpoint doesn't
+// have a path. Spans are reported with service as the service.name
+func NewOTLPExporter(endpoint, service string) *OTLPExporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if i := strings.Index(url, "://"); i == -1 || !strings.Contains(url[i+3:], "/") {
+		url += "/v1/traces"
+	}
+	return &OTLPExporter{
+		url:     url,
+		service: service,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// the OTLP json types, ids are hex and 64 bit integers are strings
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func otlpAttributeValue(key string, value interface{}) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	switch v := value.(type) {
+	case string:
+		a.Value.StringValue = &v
+	case bool:
+		a.Value.BoolValue = &v
+	case int:
+		s := strconv.Itoa(v)
+		a.Value.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		a.Value.IntValue = &s
+	case float64:
+		a.Value.DoubleValue = &v
+	default:
+		s := fmt.Sprint(v)
+		a.Value.StringValue = &s
+	}
+	return a
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// request builds the body of an export request
+func (oe *OTLPExporter) request(spans []SpanData) otlpRequest {
+	scope := otlpScopeSpans{}
+	scope.Scope.Name = "embly"
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.Context.TraceID.String(),
+			SpanID:            s.Context.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+		}
+		if s.Parent.IsValid() {
+			span.ParentSpanID = s.Parent.String()
+		}
+		for _, a := range s.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttributeValue(a.Key, a.Value))
+		}
+		if s.Error != "" {
+			// STATUS_CODE_ERROR
+			span.Status = &otlpStatus{Code: 2, Message: s.Error}
+		}
+		scope.Spans = append(scope.Spans, span)
+	}
+	resource := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scope}}
+	resource.Resource.Attributes = []otlpAttribute{otlpAttributeValue("service.name", oe.service)}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{resource}}
+}
+
+// Export posts spans to the collector
+func (oe *OTLPExporter) Export(spans []SpanData) error {
+	body, err := json.Marshal(oe.request(spans))
+	if err != nil {
+		return err
+	}
+	resp, err := oe.client.Post(oe.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("collector at %s responded with %s", oe.url, resp.Status)
+	}
+	return nil
+}
+
+// Close does nothing, spans are sent as they are exported
+func (oe *OTLPExporter) Close() error {
+	return nil
+}
@@ -0,0 +1,327 @@
+// Package tracing records spans with w3c trace context and exports them in
+// batches to an OTLP collector or a json file
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the id is set, an id of all zeros is invalid
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the id is set, an id of all zeros is invalid
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span that is passed to other processes
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether the span context has a trace and span id
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a w3c traceparent header, it's empty
+// if the span context isn't valid
+func (sc SpanContext) Traceparent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a w3c traceparent header
+func ParseTraceparent(traceparent string) (sc SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) {
+		return sc, errors.Errorf(`invalid traceparent "%s"`, traceparent)
+	}
+	var flags [1]byte
+	if err = decodeHex(sc.TraceID[:], parts[1]); err == nil {
+		if err = decodeHex(sc.SpanID[:], parts[2]); err == nil {
+			err = decodeHex(flags[:], parts[3])
+		}
+	}
+	if err != nil || !sc.IsValid() {
+		return SpanContext{}, errors.Errorf(`invalid traceparent "%s"`, traceparent)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex that exactly fills dst
+func decodeHex(dst []byte, s string) error {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return errors.New("invalid hex")
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+type contextKey struct{}
+
+// ContextWithSpanContext returns a context that carries sc
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by ctx
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(contextKey{}).(SpanContext)
+	return sc
+}
+
+// SpanKind describes a span's relationship to the other spans in a trace, the
+// values match OTLP
+type SpanKind int
+
+// The kinds of span that are recorded
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Attribute is a key and a string, bool, int, int64 or float64 value
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanData is a finished span that is handed to exporters
+type SpanData struct {
+	Name       string
+	Kind       SpanKind
+	Context    SpanContext
+	Parent     SpanID
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+	// Error is the error the span ended with, empty if it succeeded
+	Error string
+}
+
+// Span is an operation in a trace. A nil span is valid and does nothing, it's
+// returned by a nil tracer
+type Span struct {
+	tracer *Tracer
+	mutex  sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// Context returns the span's context, which is passed to child spans
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.Context
+}
+
+// SetAttribute adds an attribute to the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data.Attributes = append(s.data.Attributes, Attribute{key, value})
+}
+
+// SetError marks the span as failed, a nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.data.Error = err.Error()
+}
+
+// End finishes the span and queues it for export if it's sampled. Calls after
+// the first are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mutex.Unlock()
+	if data.Context.Sampled {
+		s.tracer.queue(data)
+	}
+}
+
+// Exporter sends finished spans somewhere
+type Exporter interface {
+	Export(spans []SpanData) error
+	Close() error
+}
+
+// The batching limits of a tracer. Spans that end while the queue is full are
+// dropped so that tracing never slows down the code being traced
+var (
+	BatchInterval = time.Second
+	maxBatchSize  = 512
+	maxQueueSize  = 4096
+)
+
+// Tracer starts spans and exports them in batches. A nil tracer records
+// nothing
+type Tracer struct {
+	exporters []Exporter
+	onError   func(error)
+	spans     chan SpanData
+	stop      chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewTracer creates a tracer that exports to every exporter. Export errors are
+// passed to onError
+func NewTracer(onError func(error), exporters ...Exporter) *Tracer {
+	t := &Tracer{
+		exporters: exporters,
+		onError:   onError,
+		spans:     make(chan SpanData, maxQueueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func randomBytes(b []byte) {
+	for {
+		_, _ = rand.Read(b)
+		for _, c := range b {
+			if c != 0 {
+				return
+			}
+		}
+	}
+}
+
+// Start starts a span. The span is a child of parent if parent is valid,
+// otherwise it starts a new sampled trace
+func (t *Tracer) Start(name string, parent SpanContext, kind SpanKind) *Span {
+	return t.StartAt(name, parent, kind, time.Now())
+}
+
+// StartAt starts a span for an operation that began at start
+func (t *Tracer) StartAt(name string, parent SpanContext, kind SpanKind, start time.Time) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{tracer: t, data: SpanData{
+		Name:  name,
+		Kind:  kind,
+		Start: start,
+	}}
+	if parent.IsValid() {
+		s.data.Context.TraceID = parent.TraceID
+		s.data.Context.Sampled = parent.Sampled
+		s.data.Parent = parent.SpanID
+	} else {
+		randomBytes(s.data.Context.TraceID[:])
+		s.data.Context.Sampled = true
+	}
+	randomBytes(s.data.Context.SpanID[:])
+	return s
+}
+
+func (t *Tracer) queue(data SpanData) {
+	select {
+	case <-t.stop:
+	case t.spans <- data:
+	default:
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(BatchInterval)
+	defer ticker.Stop()
+	batch := []SpanData{}
+	for {
+		select {
+		case data := <-t.spans:
+			if batch = append(batch, data); len(batch) >= maxBatchSize {
+				batch = t.export(batch)
+			}
+		case <-ticker.C:
+			batch = t.export(batch)
+		case <-t.stop:
+			for len(t.spans) > 0 {
+				batch = append(batch, <-t.spans)
+			}
+			t.export(batch)
+			return
+		}
+	}
+}
+
+// export sends a batch to every exporter and returns an empty batch
+func (t *Tracer) export(batch []SpanData) []SpanData {
+	if len(batch) == 0 {
+		return batch
+	}
+	for _, e := range t.exporters {
+		if err := e.Export(batch); err != nil && t.onError != nil {
+			t.onError(errors.Wrap(err, "error exporting spans"))
+		}
+	}
+	return []SpanData{}
+}
+
+// Shutdown exports the spans that have ended and closes the exporters. Spans
+// that end after shutdown are dropped
+func (t *Tracer) Shutdown(ctx context.Context) (err error) {
+	if t == nil {
+		return nil
+	}
+	t.stopOnce.Do(func() { close(t.stop) })
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		// the exporters are still in use
+		return ctx.Err()
+	}
+	for _, e := range t.exporters {
+		if closeErr := e.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
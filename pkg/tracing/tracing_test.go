@@ -0,0 +1,171 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"embly/pkg/tester"
+
+	"github.com/pkg/errors"
+)
+
+func TestTraceparent(te *testing.T) {
+	t := tester.New(te)
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(header)
+	t.PanicOnErr(err)
+	t.Assert().Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	t.Assert().Equal("00f067aa0ba902b7", sc.SpanID.String())
+	t.Assert().True(sc.Sampled)
+	t.Assert().Equal(header, sc.Traceparent())
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := ParseTraceparent(invalid)
+		t.ErrorContains(err, "invalid traceparent")
+	}
+	t.Assert().Equal("", SpanContext{}.Traceparent())
+}
+
+type memoryExporter struct {
+	mutex  sync.Mutex
+	spans  []SpanData
+	closed bool
+}
+
+func (me *memoryExporter) Export(spans []SpanData) error {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	me.spans = append(me.spans, spans...)
+	return nil
+}
+
+func (me *memoryExporter) Close() error {
+	me.closed = true
+	return nil
+}
+
+func TestTracer(te *testing.T) {
+	t := tester.New(te)
+	me := &memoryExporter{}
+	tracer := NewTracer(nil, me)
+
+	root := tracer.Start("root", SpanContext{}, SpanKindServer)
+	child := tracer.Start("child", root.Context(), SpanKindClient)
+	child.SetAttribute("db", "main")
+	child.SetError(errors.New("failed"))
+	child.End()
+	child.End()
+	root.End()
+
+	unsampled := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}}
+	tracer.Start("dropped", unsampled, SpanKindInternal).End()
+
+	t.PanicOnErr(tracer.Shutdown(context.Background()))
+	t.Assert().True(me.closed)
+	t.Assert().Len(me.spans, 2)
+	t.Assert().Equal("child", me.spans[0].Name)
+	t.Assert().Equal(root.Context().TraceID, me.spans[0].Context.TraceID)
+	t.Assert().Equal(root.Context().SpanID, me.spans[0].Parent)
+	t.Assert().Equal([]Attribute{{"db", "main"}}, me.spans[0].Attributes)
+	t.Assert().Equal("failed", me.spans[0].Error)
+	t.Assert().False(me.spans[1].Parent.IsValid())
+
+	var nilTracer *Tracer
+	span := nilTracer.Start("nothing", SpanContext{}, SpanKindInternal)
+	span.SetAttribute("a", 1)
+	span.End()
+	t.Assert().False(span.Context().IsValid())
+	t.PanicOnErr(nilTracer.Shutdown(context.Background()))
+}
+
+func testSpans() []SpanData {
+	tracer := NewTracer(nil)
+	defer tracer.Shutdown(context.Background())
+	root := tracer.Start("root", SpanContext{}, SpanKindServer)
+	child := tracer.Start("child", root.Context(), SpanKindInternal)
+	child.SetAttribute("count", 2)
+	child.SetError(errors.New("failed"))
+	return []SpanData{root.data, child.data}
+}
+
+func TestFileExporter(te *testing.T) {
+	t := tester.New(te)
+	dir, err := ioutil.TempDir("", "")
+	t.PanicOnErr(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.json")
+
+	fe, err := NewFileExporter(path)
+	t.PanicOnErr(err)
+	spans := testSpans()
+	t.PanicOnErr(fe.Export(spans))
+	t.PanicOnErr(fe.Close())
+
+	f, err := os.Open(path)
+	t.PanicOnErr(err)
+	defer f.Close()
+	lines := []map[string]interface{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := map[string]interface{}{}
+		t.PanicOnErr(json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	t.Assert().Len(lines, 2)
+	t.Assert().Equal("server", lines[0]["kind"])
+	t.Assert().Nil(lines[0]["parent_span_id"])
+	t.Assert().Equal(spans[0].Context.SpanID.String(), lines[1]["parent_span_id"])
+	t.Assert().Equal(map[string]interface{}{"count": float64(2)}, lines[1]["attributes"])
+	t.Assert().Equal("failed", lines[1]["error"])
+}
+
+func TestOTLPExporter(te *testing.T) {
+	t := tester.New(te)
+	var req otlpRequest
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		t.PanicOnErr(json.NewDecoder(r.Body).Decode(&req))
+	}))
+	defer server.Close()
+
+	spans := testSpans()
+	oe := NewOTLPExporter(server.URL, "embly")
+	t.PanicOnErr(oe.Export(spans))
+	t.Assert().Equal("/v1/traces", path)
+	resource := req.ResourceSpans[0]
+	t.Assert().Equal("service.name", resource.Resource.Attributes[0].Key)
+	t.Assert().Equal("embly", *resource.Resource.Attributes[0].Value.StringValue)
+	otlpSpans := resource.ScopeSpans[0].Spans
+	t.Assert().Len(otlpSpans, 2)
+	t.Assert().Equal(spans[0].Context.TraceID.String(), otlpSpans[1].TraceID)
+	t.Assert().Equal(spans[0].Context.SpanID.String(), otlpSpans[1].ParentSpanID)
+	t.Assert().Equal(SpanKindServer, otlpSpans[0].Kind)
+	t.Assert().Equal("2", *otlpSpans[1].Attributes[0].Value.IntValue)
+	t.Assert().Equal(2, otlpSpans[1].Status.Code)
+
+	oe = NewOTLPExporter(server.URL+"/custom", "embly")
+	t.PanicOnErr(oe.Export(spans))
+	t.Assert().Equal("/custom", path)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	t.ErrorContains(oe.Export(spans), "400 Bad Request")
+}